@@ -0,0 +1,116 @@
+package access
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os/signal"
+
+	appconfig "github.com/stupside/moley/v2/internal/app/config"
+	accesscf "github.com/stupside/moley/v2/internal/features/access/cloudflare"
+	logger "github.com/stupside/moley/v2/internal/platform/logging"
+	sys "github.com/stupside/moley/v2/internal/platform/system"
+
+	"github.com/urfave/cli/v3"
+)
+
+const (
+	configPathFlag         = "config"
+	listenFlag             = "listen"
+	serviceTokenIDFlag     = "service-token-id"
+	serviceTokenSecretFlag = "service-token-secret"
+)
+
+var Cmd = &cli.Command{
+	Name:  "access",
+	Usage: "Connect to apps protected by Cloudflare Access",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  configPathFlag,
+			Value: "moley.yml",
+			Usage: "Path to the tunnel configuration file",
+		},
+	},
+	Commands: []*cli.Command{
+		tcpCmd,
+	},
+}
+
+var tcpCmd = &cli.Command{
+	Name:        "tcp",
+	Usage:       "Open a local listener for a TCP, SSH, RDP, or SMB app",
+	ArgsUsage:   "<hostname|subdomain>",
+	Description: "Find the app exposed on <hostname>, or on <subdomain> of the ingress zone, in the tunnel configuration and run cloudflared access tcp for its hostname. Without --listen, one connection is carried over stdin and stdout, for use as an SSH ProxyCommand.",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  listenFlag,
+			Usage: "Local address to accept connections on, e.g. 127.0.0.1:2222 (default: stdin and stdout)",
+			Validator: func(v string) error {
+				if _, _, err := net.SplitHostPort(v); err != nil {
+					return fmt.Errorf("invalid listen address %q: %w", v, err)
+				}
+				return nil
+			},
+		},
+		&cli.StringFlag{
+			Name:    serviceTokenIDFlag,
+			Usage:   "Client ID of a service token, for apps gated by a non_identity policy",
+			Sources: cli.EnvVars("MOLEY_ACCESS_SERVICE_TOKEN_ID"),
+		},
+		&cli.StringFlag{
+			Name:    serviceTokenSecretFlag,
+			Usage:   "Client secret of the service token",
+			Sources: cli.EnvVars("MOLEY_ACCESS_SERVICE_TOKEN_SECRET"),
+		},
+	},
+	Action: execTCP,
+}
+
+func execTCP(ctx context.Context, cmd *cli.Command) error {
+	name := cmd.Args().First()
+	if name == "" || cmd.Args().Len() > 1 {
+		return errors.New("expected exactly one argument: the hostname or subdomain of the app")
+	}
+
+	tokenID, tokenSecret := cmd.String(serviceTokenIDFlag), cmd.String(serviceTokenSecretFlag)
+	if (tokenID == "") != (tokenSecret == "") {
+		return fmt.Errorf("--%s and --%s must be set together", serviceTokenIDFlag, serviceTokenSecretFlag)
+	}
+
+	mgr, err := appconfig.NewTunnelManager(cmd.String(configPathFlag))
+	if err != nil {
+		return fmt.Errorf("failed to create tunnel config manager: %w", err)
+	}
+	tunnelConfig, err := mgr.Get(true)
+	if err != nil {
+		return fmt.Errorf("failed to get tunnel config: %w", err)
+	}
+
+	app, ok := tunnelConfig.Ingress.FindApp(name)
+	if !ok {
+		return fmt.Errorf("no app is exposed on %q", name)
+	}
+	if !app.Target.Protocol.NeedsAccessClient() {
+		return fmt.Errorf("app %q uses protocol %s, which clients reach directly; no local listener is needed", name, app.Target.Protocol)
+	}
+
+	hostname := tunnelConfig.Ingress.Hostname(app)
+	listen := cmd.String(listenFlag)
+
+	logger.Infof("Connecting to app", map[string]any{
+		"hostname": hostname,
+		"protocol": string(app.Target.Protocol),
+		"listen":   listen,
+	})
+
+	sigCtx, cancel := signal.NotifyContext(ctx, sys.GetShutdownSignals()...)
+	defer cancel()
+
+	return accesscf.ForwardTCP(sigCtx, accesscf.ClientParams{
+		Hostname:           hostname,
+		Listen:             listen,
+		ServiceTokenID:     tokenID,
+		ServiceTokenSecret: tokenSecret,
+	})
+}
@@ -5,7 +5,11 @@ import (
 	"os"
 
 	"fmt"
+	"github.com/stupside/moley/v2/cmd/access"
 	"github.com/stupside/moley/v2/cmd/config"
+	"github.com/stupside/moley/v2/cmd/lock"
+	"github.com/stupside/moley/v2/cmd/metrics"
+	"github.com/stupside/moley/v2/cmd/state"
 	"github.com/stupside/moley/v2/cmd/tunnel"
 
 	logger "github.com/stupside/moley/v2/internal/platform/logging"
@@ -49,6 +53,10 @@ func init() {
 	app.Commands = []*cli.Command{
 		config.Cmd,
 		tunnel.Cmd,
+		state.Cmd,
+		access.Cmd,
+		metrics.Cmd,
+		lock.Cmd,
 		{
 			Name:  "info",
 			Usage: "Show detailed build information",
@@ -3,6 +3,8 @@ package config
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 
 	appconfig "github.com/stupside/moley/v2/internal/app/config"
 	logger "github.com/stupside/moley/v2/internal/platform/logging"
@@ -12,6 +14,15 @@ import (
 
 const (
 	cloudflareTokenFlag = "cloudflare.token"
+	stateBackendFlag    = "state.backend"
+	stateHTTPURLFlag    = "state.http.url"
+	stateHTTPTokenFlag  = "state.http.token"
+	stateS3EndpointFlag = "state.s3.endpoint"
+	stateS3BucketFlag   = "state.s3.bucket"
+	stateS3PrefixFlag   = "state.s3.prefix"
+	stateS3RegionFlag   = "state.s3.region"
+	stateS3KeyIDFlag    = "state.s3.access_key_id"
+	stateS3SecretFlag   = "state.s3.secret_access_key"
 )
 
 var setCmd = &cli.Command{
@@ -20,9 +31,51 @@ var setCmd = &cli.Command{
 	Description: "Set Moley configuration values using command-line flags.",
 	Flags: []cli.Flag{
 		&cli.StringFlag{
-			Name:     cloudflareTokenFlag,
-			Usage:    "Cloudflare API token",
-			Required: true,
+			Name:  cloudflareTokenFlag,
+			Usage: "Cloudflare API token",
+		},
+		&cli.StringFlag{
+			Name:  stateBackendFlag,
+			Usage: "Where lock files are stored (local, http, s3)",
+			Validator: func(v string) error {
+				backends := []string{appconfig.StateBackendLocal, appconfig.StateBackendHTTP, appconfig.StateBackendS3}
+				if !slices.Contains(backends, v) {
+					return fmt.Errorf("invalid state backend %q (expected one of %s)", v, strings.Join(backends, ", "))
+				}
+				return nil
+			},
+		},
+		&cli.StringFlag{
+			Name:  stateHTTPURLFlag,
+			Usage: "Server URL lock files are shared under (http backend)",
+		},
+		&cli.StringFlag{
+			Name:  stateHTTPTokenFlag,
+			Usage: "Bearer token for the state server (http backend)",
+		},
+		&cli.StringFlag{
+			Name:  stateS3EndpointFlag,
+			Usage: "Object store URL, defaults to AWS S3 in the region (s3 backend)",
+		},
+		&cli.StringFlag{
+			Name:  stateS3BucketFlag,
+			Usage: "Bucket lock files are shared in (s3 backend)",
+		},
+		&cli.StringFlag{
+			Name:  stateS3PrefixFlag,
+			Usage: "Key prefix of the lock files in the bucket (s3 backend)",
+		},
+		&cli.StringFlag{
+			Name:  stateS3RegionFlag,
+			Usage: "Region of the bucket (s3 backend)",
+		},
+		&cli.StringFlag{
+			Name:  stateS3KeyIDFlag,
+			Usage: "Access key ID, defaults to AWS_ACCESS_KEY_ID (s3 backend)",
+		},
+		&cli.StringFlag{
+			Name:  stateS3SecretFlag,
+			Usage: "Secret access key, defaults to AWS_SECRET_ACCESS_KEY (s3 backend)",
 		},
 	},
 	Action: execSet,
@@ -31,13 +84,50 @@ var setCmd = &cli.Command{
 func execSet(ctx context.Context, cmd *cli.Command) error {
 	logger.Info("Editing configuration")
 
+	settable := []string{
+		cloudflareTokenFlag, stateBackendFlag, stateHTTPURLFlag, stateHTTPTokenFlag,
+		stateS3EndpointFlag, stateS3BucketFlag, stateS3PrefixFlag, stateS3RegionFlag, stateS3KeyIDFlag, stateS3SecretFlag,
+	}
+	if !slices.ContainsFunc(settable, cmd.IsSet) {
+		return fmt.Errorf("nothing to set: pass at least one of --%s", strings.Join(settable, ", --"))
+	}
+
 	mgr, err := appconfig.NewGlobalManager()
 	if err != nil {
 		return fmt.Errorf("create global config manager failed: %w", err)
 	}
 
 	if err := mgr.Update(func(cfg *appconfig.GlobalConfig) {
-		cfg.Cloudflare.Token = cmd.String(cloudflareTokenFlag)
+		if cmd.IsSet(cloudflareTokenFlag) {
+			cfg.Cloudflare.Token = cmd.String(cloudflareTokenFlag)
+		}
+		if cmd.IsSet(stateBackendFlag) {
+			cfg.State.Backend = cmd.String(stateBackendFlag)
+		}
+		if cmd.IsSet(stateHTTPURLFlag) {
+			cfg.State.HTTP.URL = cmd.String(stateHTTPURLFlag)
+		}
+		if cmd.IsSet(stateHTTPTokenFlag) {
+			cfg.State.HTTP.Token = cmd.String(stateHTTPTokenFlag)
+		}
+		if cmd.IsSet(stateS3EndpointFlag) {
+			cfg.State.S3.Endpoint = cmd.String(stateS3EndpointFlag)
+		}
+		if cmd.IsSet(stateS3BucketFlag) {
+			cfg.State.S3.Bucket = cmd.String(stateS3BucketFlag)
+		}
+		if cmd.IsSet(stateS3PrefixFlag) {
+			cfg.State.S3.Prefix = cmd.String(stateS3PrefixFlag)
+		}
+		if cmd.IsSet(stateS3RegionFlag) {
+			cfg.State.S3.Region = cmd.String(stateS3RegionFlag)
+		}
+		if cmd.IsSet(stateS3KeyIDFlag) {
+			cfg.State.S3.AccessKeyID = cmd.String(stateS3KeyIDFlag)
+		}
+		if cmd.IsSet(stateS3SecretFlag) {
+			cfg.State.S3.SecretAccessKey = cmd.String(stateS3SecretFlag)
+		}
 	}); err != nil {
 		return fmt.Errorf("update global config failed: %w", err)
 	}
@@ -0,0 +1,95 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+
+	appconfig "github.com/stupside/moley/v2/internal/app/config"
+	logger "github.com/stupside/moley/v2/internal/platform/logging"
+
+	"github.com/urfave/cli/v3"
+)
+
+const (
+	configPathFlag = "config"
+	lockPathFlag   = "lock"
+	forceFlag      = "force"
+)
+
+var Cmd = &cli.Command{
+	Name:  "lock",
+	Usage: "Inspect and release tunnel state locks",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  configPathFlag,
+			Value: "moley.yml",
+			Usage: "Path to the tunnel configuration file",
+		},
+		&cli.StringFlag{
+			Name:  lockPathFlag,
+			Usage: "Path to the lock file with the local state backend (default: the config path with a .lock extension)",
+		},
+	},
+	Commands: []*cli.Command{
+		breakCmd,
+	},
+}
+
+var breakCmd = &cli.Command{
+	Name:        "break",
+	Usage:       "Release a lock left behind by a moley command that is no longer running",
+	Description: "Clear the lock of a tunnel's state when the command holding it has died. A lock whose holder is still running, or runs on another host, is only cleared with --force.",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  forceFlag,
+			Value: false,
+			Usage: "Clear the lock even if its holder may still be running",
+		},
+	},
+	Action: execBreak,
+}
+
+func execBreak(ctx context.Context, cmd *cli.Command) error {
+	configPath := cmd.String(configPathFlag)
+
+	globalMgr, err := appconfig.NewGlobalManager()
+	if err != nil {
+		return fmt.Errorf("failed to create global config manager: %w", err)
+	}
+	globalConfig, err := globalMgr.Get(false)
+	if err != nil {
+		return fmt.Errorf("failed to get global config: %w", err)
+	}
+
+	tunnelMgr, err := appconfig.NewTunnelManager(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to create tunnel config manager: %w", err)
+	}
+	tunnelConfig, err := tunnelMgr.Get(false)
+	if err != nil {
+		return fmt.Errorf("failed to get tunnel config: %w", err)
+	}
+
+	backend, err := globalConfig.State.Open(tunnelConfig.Tunnel, appconfig.LockFilePath(configPath, cmd.String(lockPathFlag)))
+	if err != nil {
+		return err
+	}
+
+	force := cmd.Bool(forceFlag)
+	logger.Infof("Breaking state lock", map[string]any{
+		"state": backend.String(),
+		"force": force,
+	})
+
+	holder, err := backend.BreakLock(ctx, force)
+	if err != nil {
+		return fmt.Errorf("failed to break lock: %w", err)
+	}
+
+	if holder == nil {
+		_, err = fmt.Fprintf(cmd.Root().Writer, "No lock holder recorded for %s.\n", backend)
+		return err
+	}
+	_, err = fmt.Fprintf(cmd.Root().Writer, "Released the lock on %s held by %s.\n", backend, holder)
+	return err
+}
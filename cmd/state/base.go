@@ -0,0 +1,268 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/stupside/moley/v2/cmd/tunnel"
+
+	appconfig "github.com/stupside/moley/v2/internal/app/config"
+	application "github.com/stupside/moley/v2/internal/app/session"
+	logger "github.com/stupside/moley/v2/internal/platform/logging"
+	framework "github.com/stupside/moley/v2/internal/platform/orchestration"
+
+	"github.com/urfave/cli/v3"
+)
+
+// The flags of tunnel.Flags read here.
+const (
+	dryRunFlag      = "dry-run"
+	configPathFlag  = "config"
+	lockPathFlag    = "lock"
+	forceFreshFlag  = "force-fresh"
+	lockTimeoutFlag = "lock-timeout"
+)
+
+const (
+	outputFlag = "output"
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// Cmd inspects and edits the lock file of a tunnel configuration. Every
+// subcommand goes through the lock file's lock, like a run.
+var Cmd = &cli.Command{
+	Name:        "state",
+	Usage:       "Inspect and edit the resources tracked in the lock file",
+	Description: "List and show tracked resources, forget one without destroying it, or import an existing Cloudflare object so Moley manages it.",
+	Flags:       tunnel.Flags(),
+	Commands: []*cli.Command{
+		stateListCmd,
+		stateShowCmd,
+		stateRmCmd,
+		stateImportCmd,
+	},
+}
+
+var stateListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "List tracked resources",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  outputFlag,
+			Value: outputText,
+			Usage: "Output format (text, json)",
+		},
+	},
+	Action: execStateList,
+}
+
+var stateShowCmd = &cli.Command{
+	Name:      "show",
+	Usage:     "Print a tracked resource as recorded",
+	ArgsUsage: "<handler>/<key>",
+	Action:    execStateShow,
+}
+
+var stateRmCmd = &cli.Command{
+	Name:        "rm",
+	Usage:       "Forget a tracked resource without destroying it",
+	Description: "Remove a resource from the lock file. It is left untouched on Cloudflare; the next run recovers it by name or creates it again if it is still configured.",
+	ArgsUsage:   "<handler>/<key>",
+	Action:      execStateRm,
+}
+
+var stateImportCmd = &cli.Command{
+	Name:        "import",
+	Usage:       "Adopt an existing Cloudflare object",
+	Description: "Record an existing object for a configured resource. The object is found by name like after a lost lock file; with an ID, it must be the object found, so a wrong ID never binds an unrelated one.",
+	ArgsUsage:   "<handler> <key> [id]",
+	Action:      execStateImport,
+}
+
+func execStateList(ctx context.Context, cmd *cli.Command) error {
+	output := cmd.String(outputFlag)
+	if output != outputText && output != outputJSON {
+		return fmt.Errorf("invalid output format %q (expected %s or %s)", output, outputText, outputJSON)
+	}
+
+	tunnelService, err := buildLockService(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to build tunnel service: %w", err)
+	}
+
+	entries, err := tunnelService.Entries(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read lock file: %w", err)
+	}
+
+	if output == outputJSON {
+		return writeJSON(cmd.Root().Writer, entries)
+	}
+
+	printEntries(cmd.Root().Writer, entries)
+	return nil
+}
+
+// printEntries writes one row per lock entry with its last recorded health.
+func printEntries(w io.Writer, entries []framework.LockEntry) {
+	if len(entries) == 0 {
+		_, _ = fmt.Fprintln(w, "No resources tracked in the lock file.")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "HANDLER\tKEY\tHEALTH\tOBSERVED")
+	for _, entry := range entries {
+		health, observed := "-", "-"
+		if entry.Health != nil {
+			health = string(entry.Health.Status)
+			if !entry.Health.ObservedAt.IsZero() {
+				observed = entry.Health.ObservedAt.Local().Format("2006-01-02 15:04:05")
+			}
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", entry.HandlerName, entry.Key, health, observed)
+	}
+	_ = tw.Flush()
+}
+
+func execStateShow(ctx context.Context, cmd *cli.Command) error {
+	handlerName, key, err := entryAddress(cmd)
+	if err != nil {
+		return err
+	}
+
+	tunnelService, err := buildLockService(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to build tunnel service: %w", err)
+	}
+
+	entries, err := tunnelService.Entries(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read lock file: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.HandlerName == handlerName && entry.Key == key {
+			return writeJSON(cmd.Root().Writer, entry)
+		}
+	}
+	return fmt.Errorf("%w: %s/%s", framework.ErrEntryNotFound, handlerName, key)
+}
+
+func execStateRm(ctx context.Context, cmd *cli.Command) error {
+	handlerName, key, err := entryAddress(cmd)
+	if err != nil {
+		return err
+	}
+	if cmd.Bool(dryRunFlag) {
+		logger.Warn("Dry run: the lock file is not changed")
+		return nil
+	}
+
+	tunnelService, err := buildLockService(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to build tunnel service: %w", err)
+	}
+
+	if _, err := tunnelService.Forget(ctx, handlerName, key); err != nil {
+		return fmt.Errorf("failed to forget resource: %w", err)
+	}
+
+	_, err = fmt.Fprintf(cmd.Root().Writer, "Forgot %s/%s; it was not destroyed.\n", handlerName, key)
+	return err
+}
+
+func execStateImport(ctx context.Context, cmd *cli.Command) error {
+	args := cmd.Args()
+	if args.Len() < 2 || args.Len() > 3 {
+		return fmt.Errorf("expected <handler> <key> [id], got %d arguments", args.Len())
+	}
+	handlerName, key, id := args.Get(0), args.Get(1), args.Get(2)
+	if cmd.Bool(dryRunFlag) {
+		logger.Warn("Dry run: the lock file is not changed")
+		return nil
+	}
+
+	tunnelService, err := tunnel.BuildService(ctx, cmd)
+	if err != nil {
+		return fmt.Errorf("failed to build tunnel service: %w", err)
+	}
+
+	entry, err := tunnelService.Import(ctx, handlerName, key, id)
+	if err != nil {
+		return fmt.Errorf("failed to import resource: %w", err)
+	}
+
+	health := "unknown"
+	if entry.Health != nil {
+		health = string(entry.Health.Status)
+	}
+	_, err = fmt.Fprintf(cmd.Root().Writer, "Imported %s/%s (%s).\n", handlerName, key, health)
+	return err
+}
+
+// entryAddress parses the <handler>/<key> argument. Keys may contain slashes,
+// handler names do not.
+func entryAddress(cmd *cli.Command) (string, string, error) {
+	if cmd.Args().Len() != 1 {
+		return "", "", fmt.Errorf("expected <handler>/<key>, got %d arguments", cmd.Args().Len())
+	}
+	handlerName, key, ok := strings.Cut(cmd.Args().First(), "/")
+	if !ok || handlerName == "" || key == "" {
+		return "", "", fmt.Errorf("invalid resource address %q (expected <handler>/<key>)", cmd.Args().First())
+	}
+	return handlerName, key, nil
+}
+
+// buildLockService returns a tunnel service for the subcommands that only read
+// or edit the lock file. It has no Cloudflare adapters, so neither an API token
+// nor the account is needed, and it cannot reconcile anything.
+func buildLockService(cmd *cli.Command) (*application.Service, error) {
+	configPath := cmd.String(configPathFlag)
+
+	globalMgr, err := appconfig.NewGlobalManager()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create global config manager: %w", err)
+	}
+	globalConfig, err := globalMgr.Get(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get global config: %w", err)
+	}
+
+	tunnelMgr, err := appconfig.NewTunnelManager(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tunnel config manager: %w", err)
+	}
+	tunnelConfig, err := tunnelMgr.Get(true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tunnel config: %w", err)
+	}
+
+	backend, err := globalConfig.State.Open(tunnelConfig.Tunnel, appconfig.LockFilePath(configPath, cmd.String(lockPathFlag)))
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []application.Option{
+		application.WithStateBackend(backend),
+		application.WithLockTimeout(cmd.Duration(lockTimeoutFlag)),
+	}
+	if cmd.Bool(forceFreshFlag) {
+		opts = append(opts, application.WithForceFresh())
+	}
+	return application.NewService(tunnelConfig.Tunnel, tunnelConfig.Ingress, tunnelConfig.Access, nil, nil, nil, nil, nil, nil, opts...), nil
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("failed to encode JSON output: %w", err)
+	}
+	return nil
+}
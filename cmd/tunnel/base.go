@@ -3,22 +3,29 @@ package tunnel
 import (
 	"context"
 	"fmt"
+	"time"
 
 	appconfig "github.com/stupside/moley/v2/internal/app/config"
 	logger "github.com/stupside/moley/v2/internal/platform/logging"
+	framework "github.com/stupside/moley/v2/internal/platform/orchestration"
 
 	"github.com/urfave/cli/v3"
 )
 
 const (
-	dryRunFlag     = "dry-run"
-	configPathFlag = "config"
+	dryRunFlag      = "dry-run"
+	configPathFlag  = "config"
+	lockPathFlag    = "lock"
+	forceFreshFlag  = "force-fresh"
+	adoptFlag       = "adopt"
+	lockTimeoutFlag = "lock-timeout"
+	concurrencyFlag = "concurrency"
 )
 
-var Cmd = &cli.Command{
-	Name:  "tunnel",
-	Usage: "Manage Cloudflare tunnels",
-	Flags: []cli.Flag{
+// Flags returns the flags shared by every command that operates on a tunnel
+// configuration and its lock file.
+func Flags() []cli.Flag {
+	return []cli.Flag{
 		&cli.BoolFlag{
 			Name:  dryRunFlag,
 			Value: false,
@@ -29,10 +36,55 @@ var Cmd = &cli.Command{
 			Value: "moley.yml",
 			Usage: "Path to the tunnel configuration file",
 		},
-	},
+		&cli.StringFlag{
+			Name:  lockPathFlag,
+			Usage: "Path to the lock file with the local state backend (default: the config path with a .lock extension)",
+		},
+		&cli.DurationFlag{
+			Name:  lockTimeoutFlag,
+			Value: framework.DefaultLockTimeout,
+			Usage: "How long to wait for a lock held by another moley command",
+			Validator: func(v time.Duration) error {
+				if v <= 0 {
+					return fmt.Errorf("lock timeout must be positive, got %s", v)
+				}
+				return nil
+			},
+		},
+		&cli.BoolFlag{
+			Name:  forceFreshFlag,
+			Value: false,
+			Usage: "Start from an empty lock file if it is corrupt (tracked resources are rediscovered by name)",
+		},
+		&cli.BoolFlag{
+			Name:  adoptFlag,
+			Value: false,
+			Usage: "Take over existing DNS records and Access applications that moley did not create for this tunnel",
+		},
+		&cli.IntFlag{
+			Name:  concurrencyFlag,
+			Value: 4,
+			Usage: "Maximum number of Cloudflare operations to run in parallel",
+			Validator: func(v int) error {
+				if v < 1 {
+					return fmt.Errorf("concurrency must be at least 1, got %d", v)
+				}
+				return nil
+			},
+		},
+	}
+}
+
+var Cmd = &cli.Command{
+	Name:  "tunnel",
+	Usage: "Manage Cloudflare tunnels",
+	Flags: Flags(),
 	Commands: []*cli.Command{
 		runCmd,
 		stopCmd,
+		planCmd,
+		driftCmd,
+		statusCmd,
 		{
 			Name:  "init",
 			Usage: "Initialize a new tunnel configuration file",
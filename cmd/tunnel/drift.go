@@ -0,0 +1,112 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	logger "github.com/stupside/moley/v2/internal/platform/logging"
+	framework "github.com/stupside/moley/v2/internal/platform/orchestration"
+
+	"github.com/urfave/cli/v3"
+)
+
+const (
+	repairFlag = "repair"
+)
+
+var driftCmd = &cli.Command{
+	Name:        "drift",
+	Usage:       "Compare live Cloudflare state with the lock file",
+	Description: "Read every tracked resource back from Cloudflare and report the fields that changed outside of Moley. With --repair, the lock file is updated so the next run restores the configured state.",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  outputFlag,
+			Value: outputText,
+			Usage: "Output format (text, json)",
+		},
+		&cli.BoolFlag{
+			Name:  repairFlag,
+			Value: false,
+			Usage: "Mark drifted resources so the next run restores them",
+		},
+	},
+	Action: execDrift,
+}
+
+func execDrift(ctx context.Context, cmd *cli.Command) error {
+	output := cmd.String(outputFlag)
+	if output != outputText && output != outputJSON {
+		return fmt.Errorf("invalid output format %q (expected %s or %s)", output, outputText, outputJSON)
+	}
+
+	dryRun := cmd.Bool(dryRunFlag)
+	repair := cmd.Bool(repairFlag)
+	if repair && dryRun {
+		logger.Warn("Dry run: drift will be reported but not repaired")
+		repair = false
+	}
+
+	logger.Infof("Detecting tunnel drift", map[string]any{
+		"dry":    dryRun,
+		"repair": repair,
+		"config": cmd.String(configPathFlag),
+	})
+
+	tunnelService, err := buildTunnelService(ctx, cmd, serviceOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to build tunnel service: %w", err)
+	}
+
+	report, err := tunnelService.Drift(ctx, repair)
+	if report == nil {
+		return err
+	}
+
+	if output == outputJSON {
+		if encErr := writeJSON(cmd.Root().Writer, report); encErr != nil {
+			return encErr
+		}
+		return err
+	}
+
+	printDrift(cmd.Root().Writer, report)
+	return err
+}
+
+// printDrift writes a human-readable drift report, one block per drifted resource.
+func printDrift(w io.Writer, report *framework.DriftReport) {
+	for _, res := range report.Resources {
+		_, _ = fmt.Fprintf(w, "%s %s\n", res.Handler, res.Key)
+
+		if res.Missing {
+			_, _ = fmt.Fprintln(w, "  ! deleted outside of moley")
+			continue
+		}
+		for _, field := range res.Fields {
+			_, _ = fmt.Fprintf(w, "  ~ %s: %s -> %s\n", field.Field, formatValue(field.Recorded), formatValue(field.Observed))
+		}
+	}
+
+	if !report.HasDrift() {
+		_, _ = fmt.Fprintf(w, "No drift: %d resources match the lock file.\n", report.Checked)
+		return
+	}
+
+	_, _ = fmt.Fprintf(w, "\nDrift: %d of %d resources changed.\n", len(report.Resources), report.Checked)
+	if report.Repaired {
+		_, _ = fmt.Fprintln(w, "Lock file updated; the next `moley tunnel run` restores the configured state.")
+	}
+}
+
+func formatValue(v any) string {
+	if v == nil {
+		return "none"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,126 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	logger "github.com/stupside/moley/v2/internal/platform/logging"
+	framework "github.com/stupside/moley/v2/internal/platform/orchestration"
+
+	"github.com/urfave/cli/v3"
+)
+
+const (
+	outputFlag = "output"
+	outputText = "text"
+	outputJSON = "json"
+)
+
+var planCmd = &cli.Command{
+	Name:        "plan",
+	Usage:       "Show what a run would change",
+	Description: "Compare the tunnel configuration with the Cloudflare account and the lock file, and print the resources a run would add, update, or remove. Nothing is created or destroyed.",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  outputFlag,
+			Value: outputText,
+			Usage: "Output format (text, json)",
+		},
+	},
+	Action: execPlan,
+}
+
+func execPlan(ctx context.Context, cmd *cli.Command) error {
+	output := cmd.String(outputFlag)
+	if output != outputText && output != outputJSON {
+		return fmt.Errorf("invalid output format %q (expected %s or %s)", output, outputText, outputJSON)
+	}
+
+	// Dry-run services answer with placeholders, so a plan against them would
+	// compare the config with fake state. Plan never changes anything anyway.
+	if cmd.Bool(dryRunFlag) {
+		return fmt.Errorf("--%s is not supported by plan, which is already read-only", dryRunFlag)
+	}
+
+	logger.Infof("Planning tunnel", map[string]any{
+		"config": cmd.String(configPathFlag),
+	})
+
+	tunnelService, err := buildTunnelService(ctx, cmd, serviceOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to build tunnel service: %w", err)
+	}
+
+	plan, err := tunnelService.Plan(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to plan tunnel service: %w", err)
+	}
+
+	if output == outputJSON {
+		return writeJSON(cmd.Root().Writer, plan)
+	}
+
+	printPlan(cmd.Root().Writer, plan)
+	return nil
+}
+
+// writeJSON writes v as indented JSON, for consumption by scripts and CI.
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("failed to encode JSON output: %w", err)
+	}
+	return nil
+}
+
+var actionSymbols = map[framework.Action]string{
+	framework.ActionAdd:    "+",
+	framework.ActionUpdate: "~",
+	framework.ActionRemove: "-",
+}
+
+// printPlan writes a human-readable plan, grouped by handler in dependency order.
+func printPlan(w io.Writer, plan *framework.Plan) {
+	for _, node := range plan.Nodes {
+		_, _ = fmt.Fprintln(w, node.Handler)
+
+		if node.Pending != "" {
+			_, _ = fmt.Fprintf(w, "  ? known after apply (%s)\n", node.Pending)
+			continue
+		}
+		if len(node.Changes) == 0 {
+			_, _ = fmt.Fprintln(w, "  (no changes)")
+			continue
+		}
+
+		for _, change := range node.Changes {
+			switch {
+			case change.Reason != "":
+				_, _ = fmt.Fprintf(w, "  %s %s (%s)\n", actionSymbols[change.Action], change.Key, change.Reason)
+			case change.Action == framework.ActionUpdate:
+				_, _ = fmt.Fprintf(w, "  %s %s (%s -> %s)\n", actionSymbols[change.Action], change.Key, shortHash(change.OldHash), shortHash(change.NewHash))
+			default:
+				_, _ = fmt.Fprintf(w, "  %s %s\n", actionSymbols[change.Action], change.Key)
+			}
+		}
+	}
+
+	_, _ = fmt.Fprintf(w, "\nPlan: %d to add, %d to update, %d to remove.\n",
+		plan.Count(framework.ActionAdd),
+		plan.Count(framework.ActionUpdate),
+		plan.Count(framework.ActionRemove),
+	)
+}
+
+func shortHash(hash string) string {
+	if hash == "" {
+		return "none"
+	}
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
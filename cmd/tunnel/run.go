@@ -3,7 +3,10 @@ package tunnel
 import (
 	"context"
 	"fmt"
+	"time"
 
+	application "github.com/stupside/moley/v2/internal/app/session"
+	tunnelcf "github.com/stupside/moley/v2/internal/features/tunnel/cloudflare"
 	logger "github.com/stupside/moley/v2/internal/platform/logging"
 	shared "github.com/stupside/moley/v2/internal/platform/runtime"
 
@@ -11,7 +14,11 @@ import (
 )
 
 const (
-	detachFlag = "detach"
+	detachFlag        = "detach"
+	watchIntervalFlag = "watch-interval"
+	maxRestartsFlag   = "max-restarts"
+	logFileFlag       = "log-file"
+	noWatchConfigFlag = "no-watch-config"
 )
 
 var runCmd = &cli.Command{
@@ -24,20 +31,70 @@ var runCmd = &cli.Command{
 			Value: false,
 			Usage: "Run the tunnel in the background (detached mode)",
 		},
+		&cli.DurationFlag{
+			Name:  watchIntervalFlag,
+			Value: 0,
+			Usage: "Re-reconcile at this interval while running in the foreground, recreating missing resources (0 disables)",
+			Validator: func(v time.Duration) error {
+				if v < 0 {
+					return fmt.Errorf("watch interval must not be negative, got %s", v)
+				}
+				return nil
+			},
+		},
+		&cli.IntFlag{
+			Name:  maxRestartsFlag,
+			Value: 5,
+			Usage: "Consecutive cloudflared crashes to restart before giving up (foreground only)",
+			Validator: func(v int) error {
+				if v < 0 {
+					return fmt.Errorf("max restarts must not be negative, got %d", v)
+				}
+				return nil
+			},
+		},
+		&cli.BoolFlag{
+			Name:  logFileFlag,
+			Value: false,
+			Usage: "Also write cloudflared output to ~/.moley/tunnels/<name>.log, rotated at 10 MB (foreground only)",
+		},
+		&cli.BoolFlag{
+			Name:  noWatchConfigFlag,
+			Value: false,
+			Usage: "Do not apply changes to the config file while running in the foreground",
+		},
 	},
 	Action: execRun,
 }
 
 func execRun(ctx context.Context, cmd *cli.Command) error {
 	detach := cmd.Bool(detachFlag)
+	watchInterval := cmd.Duration(watchIntervalFlag)
 
 	logger.Infof("Starting tunnel", map[string]any{
 		"dry":    cmd.Bool(dryRunFlag),
 		"detach": detach,
+		"watch":  watchInterval.String(),
 		"config": cmd.String(configPathFlag),
 	})
 
-	tunnelService, err := buildTunnelService(ctx, cmd)
+	if detach && watchInterval > 0 {
+		logger.Warn("Watch mode requires the foreground; ignoring --watch-interval with --detach")
+	}
+
+	opts := serviceOptions{
+		session:     []application.Option{application.WithWatchInterval(watchInterval)},
+		watchConfig: !detach && !cmd.Bool(noWatchConfigFlag),
+	}
+	// A detached cloudflared must outlive moley, so only the foreground supervises it.
+	if !detach {
+		opts.tunnel = append(opts.tunnel, tunnelcf.WithSupervision(cmd.Int(maxRestartsFlag)))
+		if cmd.Bool(logFileFlag) {
+			opts.tunnel = append(opts.tunnel, tunnelcf.WithLogFile())
+		}
+	}
+
+	tunnelService, err := buildTunnelService(ctx, cmd, opts)
 	if err != nil {
 		return fmt.Errorf("failed to build tunnel service: %w", err)
 	}
@@ -0,0 +1,84 @@
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	logger "github.com/stupside/moley/v2/internal/platform/logging"
+	framework "github.com/stupside/moley/v2/internal/platform/orchestration"
+
+	"github.com/urfave/cli/v3"
+)
+
+var statusCmd = &cli.Command{
+	Name:        "status",
+	Usage:       "Show the state of every managed resource",
+	Description: "Read the lock file without modifying it, check each tracked resource against Cloudflare and the local machine, and print whether it is up, down, or unknown.",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  outputFlag,
+			Value: outputText,
+			Usage: "Output format (text, json)",
+		},
+	},
+	Action: execStatus,
+}
+
+func execStatus(ctx context.Context, cmd *cli.Command) error {
+	output := cmd.String(outputFlag)
+	if output != outputText && output != outputJSON {
+		return fmt.Errorf("invalid output format %q (expected %s or %s)", output, outputText, outputJSON)
+	}
+
+	logger.Infof("Checking tunnel status", map[string]any{
+		"dry":    cmd.Bool(dryRunFlag),
+		"config": cmd.String(configPathFlag),
+	})
+
+	tunnelService, err := buildTunnelService(ctx, cmd, serviceOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to build tunnel service: %w", err)
+	}
+
+	report, err := tunnelService.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check tunnel status: %w", err)
+	}
+
+	if output == outputJSON {
+		return writeJSON(cmd.Root().Writer, report)
+	}
+
+	printStatus(cmd.Root().Writer, report)
+	return nil
+}
+
+// printStatus writes one row per tracked resource, in dependency order.
+func printStatus(w io.Writer, report *framework.StatusReport) {
+	if len(report.Resources) == 0 {
+		_, _ = fmt.Fprintln(w, "No resources tracked in the lock file.")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "HANDLER\tKEY\tSTATUS\tDETAILS")
+	for _, res := range report.Resources {
+		details := res.Details
+		if res.Reason != "" {
+			details = strings.TrimPrefix(fmt.Sprintf("%s (%s)", details, res.Reason), " ")
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", res.Handler, res.Key, res.Status, details)
+	}
+	_ = tw.Flush()
+
+	_, _ = fmt.Fprintf(w, "\n%d resources: %d up, %d degraded, %d down, %d unknown.\n",
+		len(report.Resources),
+		report.Count(framework.StatusUp),
+		report.Count(framework.StatusDegraded),
+		report.Count(framework.StatusDown),
+		report.Count(framework.StatusUnknown),
+	)
+}
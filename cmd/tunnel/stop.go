@@ -21,7 +21,7 @@ func execStop(ctx context.Context, cmd *cli.Command) error {
 		"config": cmd.String(configPathFlag),
 	})
 
-	tunnelService, err := buildTunnelService(ctx, cmd)
+	tunnelService, err := buildTunnelService(ctx, cmd, serviceOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to build tunnel service: %w", err)
 	}
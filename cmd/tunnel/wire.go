@@ -7,17 +7,34 @@ import (
 	appconfig "github.com/stupside/moley/v2/internal/app/config"
 	application "github.com/stupside/moley/v2/internal/app/session"
 	accesscf "github.com/stupside/moley/v2/internal/features/access/cloudflare"
-	accessusecase "github.com/stupside/moley/v2/internal/features/access/usecase"
 	dnscf "github.com/stupside/moley/v2/internal/features/dns/cloudflare"
 	tunnelcf "github.com/stupside/moley/v2/internal/features/tunnel/cloudflare"
+	platformconfig "github.com/stupside/moley/v2/internal/platform/config"
+	logger "github.com/stupside/moley/v2/internal/platform/logging"
+	"github.com/stupside/moley/v2/internal/platform/metrics"
 
 	cfgo "github.com/cloudflare/cloudflare-go/v3"
 	"github.com/cloudflare/cloudflare-go/v3/option"
 	"github.com/urfave/cli/v3"
 )
 
+// serviceOptions carries settings of a specific command into buildTunnelService,
+// on top of the ones derived from the shared tunnel flags.
+type serviceOptions struct {
+	session []application.Option
+	tunnel  []tunnelcf.Option
+
+	// watchConfig applies edits of the config file to the running service.
+	watchConfig bool
+}
+
+// BuildService returns the tunnel service of a command that takes Flags.
+func BuildService(ctx context.Context, cmd *cli.Command) (*application.Service, error) {
+	return buildTunnelService(ctx, cmd, serviceOptions{})
+}
+
 // buildTunnelService creates the Cloudflare adapters and returns a ready-to-use tunnel service.
-func buildTunnelService(ctx context.Context, cmd *cli.Command) (*application.Service, error) {
+func buildTunnelService(ctx context.Context, cmd *cli.Command, opts serviceOptions) (*application.Service, error) {
 	dryRun := cmd.Bool(dryRunFlag)
 	configPath := cmd.String(configPathFlag)
 
@@ -45,20 +62,64 @@ func buildTunnelService(ctx context.Context, cmd *cli.Command) (*application.Ser
 		option.WithAPIToken(globalConfig.Cloudflare.Token),
 	)
 
-	cfTunnel, err := tunnelcf.NewTunnelService(ctx, cfClient, tunnelConfig.Ingress.Zone, dryRun)
+	cfTunnel, err := tunnelcf.NewTunnelService(ctx, cfClient, tunnelConfig.Ingress.Zones(), dryRun, opts.tunnel...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Cloudflare tunnel service: %w", err)
 	}
 
 	cfDNS := dnscf.NewDNSService(cfClient, dryRun)
 
-	var cfAccess accessusecase.AccessManager
-	var cfPolicy accessusecase.PolicyManager
-	if tunnelConfig.Access.HasPolicies() || tunnelConfig.Ingress.HasAccessConfig() {
-		svc := accesscf.NewAccessService(cfClient, cfTunnel.AccountID(), dryRun)
-		cfAccess = svc
-		cfPolicy = svc
+	// Always available: a reloaded config may add Access protection to a running tunnel.
+	cfAccess := accesscf.NewAccessService(cfClient, cfTunnel.AccountID(), dryRun)
+
+	backend, err := globalConfig.State.Open(tunnelConfig.Tunnel, appconfig.LockFilePath(configPath, cmd.String(lockPathFlag)))
+	if err != nil {
+		return nil, err
+	}
+
+	sessionOpts := append([]application.Option{
+		application.WithStateBackend(backend),
+		application.WithConcurrency(cmd.Int(concurrencyFlag)),
+		application.WithLockTimeout(cmd.Duration(lockTimeoutFlag)),
+	}, opts.session...)
+
+	if opts.watchConfig {
+		sessionOpts = append(sessionOpts, application.WithConfigWatcher(watchTunnelConfig(tunnelMgr)))
 	}
 
-	return application.NewService(tunnelConfig.Tunnel, tunnelConfig.Ingress, tunnelConfig.Access, cfDNS, cfTunnel, cfTunnel, cfTunnel, cfAccess, cfPolicy), nil
+	if cmd.Bool(forceFreshFlag) {
+		sessionOpts = append(sessionOpts, application.WithForceFresh())
+	}
+	if cmd.Bool(adoptFlag) {
+		sessionOpts = append(sessionOpts, application.WithAdopt())
+	}
+
+	// Dry runs change nothing, so they are not counted.
+	if !dryRun {
+		statePath, err := tunnelcf.MetricsStatePath(tunnelConfig.Tunnel)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get metrics state path: %w", err)
+		}
+		sessionOpts = append(sessionOpts, application.WithRecorder(metrics.NewFileRecorder(statePath)))
+	}
+
+	return application.NewService(tunnelConfig.Tunnel, tunnelConfig.Ingress, tunnelConfig.Access, cfDNS, cfTunnel, cfTunnel, cfTunnel, cfAccess, cfAccess, sessionOpts...), nil
+}
+
+// watchTunnelConfig feeds validated changes of the tunnel config file to a running
+// service. Invalid edits are logged and skipped, so the tunnel keeps its last good state.
+func watchTunnelConfig(mgr *platformconfig.Manager[appconfig.TunnelConfig]) application.ConfigWatcher {
+	return func(ctx context.Context, onChange func(application.Config)) error {
+		return mgr.Watch(ctx, func(cfg *appconfig.TunnelConfig, err error) {
+			if err != nil {
+				logger.Warnf("Ignoring invalid tunnel configuration", map[string]any{"error": err.Error()})
+				return
+			}
+			onChange(application.Config{
+				Tunnel:  cfg.Tunnel,
+				Ingress: cfg.Ingress,
+				Access:  cfg.Access,
+			})
+		})
+	}
 }
@@ -8,11 +8,47 @@ import (
 	"github.com/stupside/moley/v2/internal/platform/paths"
 )
 
+// State backends selectable in the global configuration.
+const (
+	StateBackendLocal = "local"
+	StateBackendHTTP  = "http"
+	StateBackendS3    = "s3"
+)
+
 // GlobalConfig represents the global application configuration
 type GlobalConfig struct {
 	Cloudflare struct {
 		Token string `yaml:"token" validate:"required"`
 	} `yaml:"cloudflare"`
+	State StateConfig `yaml:"state"`
+}
+
+// StateConfig selects where lock files are stored. The local backend keeps
+// them next to the tunnel configuration; the http and s3 backends share them
+// through a server so every machine can operate the same tunnels.
+type StateConfig struct {
+	Backend string          `yaml:"backend" validate:"omitempty,oneof=local http s3"`
+	HTTP    HTTPStateConfig `yaml:"http"`
+	S3      S3StateConfig   `yaml:"s3"`
+}
+
+// HTTPStateConfig locates the server used by the http backend. Each
+// tunnel is stored at <url>/<tunnel name>.json.
+type HTTPStateConfig struct {
+	URL   string `yaml:"url" validate:"omitempty,http_url"`
+	Token string `yaml:"token,omitempty"`
+}
+
+// S3StateConfig locates the bucket used by the s3 backend. Each tunnel is
+// stored at <endpoint>/<bucket>/<prefix>/<tunnel name>.json. Credentials left
+// empty are read from the standard AWS environment variables.
+type S3StateConfig struct {
+	Endpoint        string `yaml:"endpoint,omitempty" validate:"omitempty,http_url"`
+	Bucket          string `yaml:"bucket"`
+	Prefix          string `yaml:"prefix,omitempty"`
+	Region          string `yaml:"region,omitempty"`
+	AccessKeyID     string `yaml:"access_key_id,omitempty"`
+	SecretAccessKey string `yaml:"secret_access_key,omitempty"`
 }
 
 // NewGlobalManager creates a new global configuration manager
@@ -46,5 +82,8 @@ func defaultGlobalConfig() *GlobalConfig {
 		Cloudflare: struct {
 			Token string `yaml:"token" validate:"required"`
 		}{},
+		State: StateConfig{
+			Backend: StateBackendLocal,
+		},
 	}
 }
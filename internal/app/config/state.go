@@ -0,0 +1,75 @@
+package config
+
+import (
+	"cmp"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/stupside/moley/v2/internal/domain"
+	framework "github.com/stupside/moley/v2/internal/platform/orchestration"
+	"github.com/stupside/moley/v2/internal/platform/state"
+)
+
+// LockFilePath returns override, or the tunnel config path with a .lock
+// extension so every tunnel configuration keeps its own state.
+func LockFilePath(configPath, override string) string {
+	if override != "" {
+		return override
+	}
+	return strings.TrimSuffix(configPath, filepath.Ext(configPath)) + ".lock"
+}
+
+// Open returns the lock file storage for tunnel. Shared backends key state
+// by tunnel name, so lockPath only applies to the local backend.
+func (c StateConfig) Open(tunnel *domain.Tunnel, lockPath string) (framework.Backend, error) {
+	switch c.Backend {
+	case "", StateBackendLocal:
+		return framework.NewFileBackend(lockPath), nil
+	case StateBackendHTTP:
+		if c.HTTP.URL == "" {
+			return nil, fmt.Errorf("state.http.url is required by the %s state backend", StateBackendHTTP)
+		}
+		objectURL := strings.TrimSuffix(c.HTTP.URL, "/") + "/" + url.PathEscape(tunnel.GetName()+".json")
+		return state.NewHTTPBackend(objectURL, c.HTTP.Token), nil
+	case StateBackendS3:
+		return c.S3.open(tunnel)
+	default:
+		return nil, fmt.Errorf("unknown state backend %q", c.Backend)
+	}
+}
+
+// defaultS3Region is used when neither the config nor AWS_REGION names one.
+const defaultS3Region = "us-east-1"
+
+// open returns the S3 backend for tunnel. Objects are addressed path-style,
+// which AWS and S3-compatible stores such as MinIO both accept.
+func (c S3StateConfig) open(tunnel *domain.Tunnel) (framework.Backend, error) {
+	if c.Bucket == "" {
+		return nil, fmt.Errorf("state.s3.bucket is required by the %s state backend", StateBackendS3)
+	}
+
+	creds := state.S3Credentials{
+		Region:          cmp.Or(c.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), defaultS3Region),
+		AccessKeyID:     cmp.Or(c.AccessKeyID, os.Getenv("AWS_ACCESS_KEY_ID")),
+		SecretAccessKey: cmp.Or(c.SecretAccessKey, os.Getenv("AWS_SECRET_ACCESS_KEY")),
+	}
+	if c.AccessKeyID == "" {
+		// A session token only goes with the credentials it was issued for.
+		creds.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return nil, fmt.Errorf("the %s state backend needs state.s3.access_key_id and state.s3.secret_access_key, or AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY", StateBackendS3)
+	}
+
+	endpoint := cmp.Or(c.Endpoint, "https://s3."+creds.Region+".amazonaws.com")
+	segments := []string{strings.TrimSuffix(endpoint, "/"), url.PathEscape(c.Bucket)}
+	if prefix := strings.Trim(c.Prefix, "/"); prefix != "" {
+		segments = append(segments, prefix)
+	}
+	segments = append(segments, url.PathEscape(tunnel.GetName()+".json"))
+
+	return state.NewS3Backend(strings.Join(segments, "/"), creds), nil
+}
@@ -15,6 +15,11 @@ type TunnelConfig struct {
 	Access  *domain.Access  `yaml:"access,omitempty"`
 }
 
+// Validate checks the rules of the ingress that struct tags cannot express.
+func (c *TunnelConfig) Validate() error {
+	return c.Ingress.Validate()
+}
+
 // NewTunnelManager creates a new tunnel configuration manager
 func NewTunnelManager(path string) (*platformconfig.Manager[TunnelConfig], error) {
 	defaultConfig, err := defaultTunnelConfig()
@@ -11,8 +11,28 @@ import (
 	framework "github.com/stupside/moley/v2/internal/platform/orchestration"
 )
 
-func (s *Service) createOrchestrator(_ context.Context) (*framework.Reconciler, error) {
-	orchestrator, err := framework.NewReconciler()
+func (s *Service) createOrchestrator(_ context.Context, opts ...framework.ReconcilerOption) (*framework.Reconciler, error) {
+	// The lock file is bound to the tunnel, so it is never applied to another one.
+	opts = append([]framework.ReconcilerOption{
+		framework.WithConcurrency(s.concurrency),
+		framework.WithIdentity(s.tunnel.GetName()),
+	}, opts...)
+	if s.lockTimeout > 0 {
+		opts = append(opts, framework.WithLockTimeout(s.lockTimeout))
+	}
+	if s.forceFresh {
+		opts = append(opts, framework.WithForceFresh())
+	}
+	if s.adopt {
+		opts = append(opts, framework.WithAdopt())
+	}
+	if s.stateBackend != nil {
+		opts = append(opts, framework.WithBackend(s.stateBackend))
+	}
+	if s.recorder != nil {
+		opts = append(opts, framework.WithRecorder(s.recorder))
+	}
+	orchestrator, err := framework.NewReconciler(opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create resource orchestrator: %w", err)
 	}
@@ -42,9 +62,10 @@ func (s *Service) createOrchestrator(_ context.Context) (*framework.Reconciler,
 					TunnelUUID: create.TunnelUUID,
 					Persistent: s.tunnel.Persistent,
 					Ingress: &domain.Ingress{
-						Zone: s.ingress.Zone,
-						Apps: s.ingress.Apps,
-						Mode: s.ingress.Mode,
+						Zone:   s.ingress.Zone,
+						Apps:   s.ingress.Apps,
+						Mode:   s.ingress.Mode,
+						Origin: s.ingress.Origin,
 					},
 				},
 			}, nil
@@ -82,20 +103,30 @@ func (s *Service) createOrchestrator(_ context.Context) (*framework.Reconciler,
 
 			switch s.ingress.Mode {
 			case domain.IngressModeWildcard:
-				inputs = []dnsusecase.RecordInput{
-					{
-						Zone:       s.ingress.Zone,
+				// One wildcard record per zone apps are exposed in.
+				zones := s.ingress.Zones()
+				inputs = make([]dnsusecase.RecordInput, 0, len(zones))
+				for _, zone := range zones {
+					inputs = append(inputs, dnsusecase.RecordInput{
+						Zone:       zone,
 						Subdomain:  "*",
 						TunnelName: s.tunnel.Ref(),
 						TunnelUUID: create.TunnelUUID,
 						Persistent: s.tunnel.Persistent,
-					},
+					})
 				}
 			case domain.IngressModeSubdomain:
+				// Apps routed by path share their hostname, and so its record.
 				inputs = make([]dnsusecase.RecordInput, 0, len(s.ingress.Apps))
+				seen := make(map[string]bool, len(s.ingress.Apps))
 				for _, app := range s.ingress.Apps {
+					hostname := s.ingress.Hostname(app)
+					if seen[hostname] {
+						continue
+					}
+					seen[hostname] = true
 					inputs = append(inputs, dnsusecase.RecordInput{
-						Zone:       s.ingress.Zone,
+						Zone:       s.ingress.AppZone(app),
 						Subdomain:  app.Expose.Subdomain,
 						TunnelName: s.tunnel.Ref(),
 						TunnelUUID: create.TunnelUUID,
@@ -111,61 +142,72 @@ func (s *Service) createOrchestrator(_ context.Context) (*framework.Reconciler,
 		tunnelusecase.CreateHandlerName,
 	)
 
-	// access-policies — no dependencies, account-level endpoint
-	if s.policyService != nil && s.access.HasPolicies() {
-		framework.Register(orchestrator, accessusecase.NewPolicyHandler(s.policyService),
-			func(reg *framework.OutputRegistry) ([]accessusecase.PolicyInput, error) {
-				inputs := make([]accessusecase.PolicyInput, len(s.access.Policies))
-				for i, p := range s.access.Policies {
-					inputs[i] = accessusecase.PolicyInput{Policy: p}
+	// access-policies — no dependencies, account-level endpoint. Registered even
+	// without policies, so removing the last one destroys it instead of orphaning it.
+	framework.Register(orchestrator, accessusecase.NewPolicyHandler(s.policyService),
+		func(reg *framework.OutputRegistry) ([]accessusecase.PolicyInput, error) {
+			policies := s.policies()
+			inputs := make([]accessusecase.PolicyInput, len(policies))
+			for i, p := range policies {
+				inputs[i] = accessusecase.PolicyInput{Policy: p, TunnelName: s.tunnel.Ref()}
+			}
+			return inputs, nil
+		},
+	)
+
+	// access-app — depends on dns-record (ordering) and access-policies (policy IDs).
+	// Registered even without protected apps, for the same reason.
+	framework.Register(orchestrator, accessusecase.NewHandler(s.accessService),
+		func(reg *framework.OutputRegistry) ([]accessusecase.AppInput, error) {
+			policies := s.policies()
+			policyIDByName := make(map[string]string, len(policies))
+			for _, p := range policies {
+				out, ok := framework.GetOutput[accessusecase.PolicyOutput](reg, accessusecase.PolicyHandlerName, p.Name)
+				if !ok {
+					return nil, fmt.Errorf("%s: missing output for policy %q", accessusecase.HandlerName, p.Name)
 				}
-				return inputs, nil
-			},
-		)
-	}
+				policyIDByName[p.Name] = out.PolicyID
+			}
 
-	// access-app — depends on dns-record (ordering) and access-policies (policy IDs)
-	if s.accessService != nil && s.ingress.HasAccessConfig() {
-		deps := []string{dnsusecase.HandlerName}
-		if s.policyService != nil && s.access.HasPolicies() {
-			deps = append(deps, accessusecase.PolicyHandlerName)
-		}
-		framework.Register(orchestrator, accessusecase.NewHandler(s.accessService),
-			func(reg *framework.OutputRegistry) ([]accessusecase.AppInput, error) {
-				policyIDByName := make(map[string]string, len(s.access.Policies))
-				for _, p := range s.access.Policies {
-					out, ok := framework.GetOutput[accessusecase.PolicyOutput](reg, accessusecase.PolicyHandlerName, p.Name)
+			var inputs []accessusecase.AppInput
+			for _, app := range s.ingress.Apps {
+				if app.Access == nil {
+					continue
+				}
+				policyIDs := make([]string, 0, len(app.Policies))
+				for _, name := range app.Policies {
+					id, ok := policyIDByName[name]
 					if !ok {
-						return nil, fmt.Errorf("%s: missing output for policy %q", accessusecase.HandlerName, p.Name)
+						return nil, fmt.Errorf("policy %q referenced in app %q is not defined in access.policies", name, app.Expose.Subdomain)
 					}
-					policyIDByName[p.Name] = out.PolicyID
+					policyIDs = append(policyIDs, id)
 				}
-
-				var inputs []accessusecase.AppInput
-				for _, app := range s.ingress.Apps {
-					if app.Access == nil {
-						continue
-					}
-					policyIDs := make([]string, 0, len(app.Policies))
-					for _, name := range app.Policies {
-						id, ok := policyIDByName[name]
-						if !ok {
-							return nil, fmt.Errorf("policy %q referenced in app %q is not defined in access.policies", name, app.Expose.Subdomain)
-						}
-						policyIDs = append(policyIDs, id)
-					}
-					inputs = append(inputs, accessusecase.AppInput{
-						Zone:      s.ingress.Zone,
-						Subdomain: app.Expose.Subdomain,
-						Access:    *app.Access,
-						PolicyIDs: policyIDs,
-					})
+				path, err := app.Expose.AccessPath()
+				if err != nil {
+					return nil, fmt.Errorf("app %q: %w", app.Expose.Subdomain, err)
 				}
-				return inputs, nil
-			},
-			deps...,
-		)
-	}
+				inputs = append(inputs, accessusecase.AppInput{
+					Zone:       s.ingress.AppZone(app),
+					Subdomain:  app.Expose.Subdomain,
+					Path:       path,
+					TunnelName: s.tunnel.Ref(),
+					Access:     *app.Access,
+					PolicyIDs:  policyIDs,
+				})
+			}
+			return inputs, nil
+		},
+		dnsusecase.HandlerName,
+		accessusecase.PolicyHandlerName,
+	)
 
 	return orchestrator, nil
 }
+
+// policies returns the configured Access policies, none when access is not configured.
+func (s *Service) policies() []domain.Policy {
+	if !s.access.HasPolicies() {
+		return nil
+	}
+	return s.access.Policies
+}
@@ -5,12 +5,17 @@ package session
 import (
 	"context"
 	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/stupside/moley/v2/internal/domain"
 	accessusecase "github.com/stupside/moley/v2/internal/features/access/usecase"
 	dnsusecase "github.com/stupside/moley/v2/internal/features/dns/usecase"
 	tunnelusecase "github.com/stupside/moley/v2/internal/features/tunnel/usecase"
 	logger "github.com/stupside/moley/v2/internal/platform/logging"
+	framework "github.com/stupside/moley/v2/internal/platform/orchestration"
 	shared "github.com/stupside/moley/v2/internal/platform/runtime"
 )
 
@@ -24,9 +29,98 @@ type Service struct {
 	tunnelRunner       tunnelusecase.TunnelRunner
 	accessService      accessusecase.AccessManager
 	policyService      accessusecase.PolicyManager
+	concurrency        int
+	watchInterval      time.Duration
+	configWatcher      ConfigWatcher
+	recorder           framework.Recorder
+	stateBackend       framework.Backend
+	forceFresh         bool
+	adopt              bool
+	lockTimeout        time.Duration
+
+	// mu serializes reconciliation passes; stopped makes passes after Stop no-ops.
+	mu      sync.Mutex
+	stopped bool
 }
 
-var _ shared.Runnable = (*Service)(nil)
+var (
+	_ shared.Runnable = (*Service)(nil)
+	_ shared.Watcher  = (*Service)(nil)
+)
+
+// Option configures optional Service behaviour.
+type Option func(*Service)
+
+// WithConcurrency bounds the number of Cloudflare operations the reconciler runs at once.
+func WithConcurrency(limit int) Option {
+	return func(s *Service) {
+		s.concurrency = limit
+	}
+}
+
+// WithWatchInterval makes Watch re-run the reconciler about every interval while
+// the tunnel runs, recreating resources that disappeared. Zero disables watching.
+func WithWatchInterval(interval time.Duration) Option {
+	return func(s *Service) {
+		s.watchInterval = interval
+	}
+}
+
+// Config is the desired state a running Service converges to.
+type Config struct {
+	Tunnel  *domain.Tunnel
+	Ingress *domain.Ingress
+	Access  *domain.Access
+}
+
+// ConfigWatcher blocks until ctx is done, calling onChange with every new valid configuration.
+type ConfigWatcher func(ctx context.Context, onChange func(Config)) error
+
+// WithConfigWatcher makes Watch apply configuration changes delivered by w, so
+// only the affected DNS records, Access applications, and ingress rules change.
+func WithConfigWatcher(w ConfigWatcher) Option {
+	return func(s *Service) {
+		s.configWatcher = w
+	}
+}
+
+// WithRecorder reports every resource operation to rec, for metrics.
+func WithRecorder(rec framework.Recorder) Option {
+	return func(s *Service) {
+		s.recorder = rec
+	}
+}
+
+// WithStateBackend stores the session's lock file in backend, so several
+// tunnel configurations, or several machines, never share state by accident.
+func WithStateBackend(backend framework.Backend) Option {
+	return func(s *Service) {
+		s.stateBackend = backend
+	}
+}
+
+// WithForceFresh starts from an empty lock file when the stored one is corrupt,
+// instead of refusing to run.
+func WithForceFresh() Option {
+	return func(s *Service) {
+		s.forceFresh = true
+	}
+}
+
+// WithAdopt takes over existing Cloudflare resources that lack moley's
+// ownership marker for the tunnel, instead of refusing to reuse them.
+func WithAdopt() Option {
+	return func(s *Service) {
+		s.adopt = true
+	}
+}
+
+// WithLockTimeout bounds how long each reconcile pass waits for the lock file.
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(s *Service) {
+		s.lockTimeout = timeout
+	}
+}
 
 func NewService(
 	tunnel *domain.Tunnel,
@@ -38,8 +132,9 @@ func NewService(
 	tunnelRunner tunnelusecase.TunnelRunner,
 	accessService accessusecase.AccessManager,
 	policyService accessusecase.PolicyManager,
+	opts ...Option,
 ) *Service {
-	return &Service{
+	s := &Service{
 		tunnel:             tunnel,
 		ingress:            ingress,
 		access:             access,
@@ -49,29 +144,174 @@ func NewService(
 		tunnelRunner:       tunnelRunner,
 		accessService:      accessService,
 		policyService:      policyService,
+		concurrency:        1,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 func (s *Service) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	logger.Infof("Starting tunnel service", map[string]any{
 		"zone":   s.ingress.Zone,
 		"tunnel": s.tunnel.Ref(),
 	})
 
+	if err := s.reconcile(ctx); err != nil {
+		return fmt.Errorf("failed to start resources: %w", err)
+	}
+
+	logger.Info("Tunnel service started")
+	return nil
+}
+
+// reconcile runs one reconciliation pass; the caller must hold mu.
+func (s *Service) reconcile(ctx context.Context) error {
 	orch, err := s.createOrchestrator(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to create orchestrator: %w", err)
 	}
+	return orch.Start(ctx)
+}
 
-	if err := orch.Start(ctx); err != nil {
-		return fmt.Errorf("failed to start resources: %w", err)
+// Watch keeps the running tunnel converged until ctx is done. With a watch
+// interval, it periodically reconciles so a deleted DNS record or a dead
+// cloudflared process is recreated; with a config watcher, it applies
+// configuration changes as they arrive. Failed passes are logged, not fatal.
+// A restart of the supervised tunnel process triggers a pass so the lock file
+// records it; a supervisor giving up is returned as an error.
+func (s *Service) Watch(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+
+	if s.configWatcher != nil {
+		wg.Go(func() {
+			err := s.configWatcher(ctx, func(cfg Config) {
+				s.reload(ctx, cfg)
+			})
+			if err != nil {
+				logger.Warnf("Configuration watch stopped", map[string]any{"error": err.Error()})
+			}
+		})
 	}
 
-	logger.Info("Tunnel service started")
-	return nil
+	if s.watchInterval > 0 {
+		wg.Go(func() {
+			s.healLoop(ctx)
+		})
+	}
+
+	err := s.watchProcess(ctx)
+
+	cancel()
+	wg.Wait()
+	return err
+}
+
+// watchProcess follows the supervised tunnel process until ctx is done.
+func (s *Service) watchProcess(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-s.tunnelRunner.Events():
+			if event.Err != nil {
+				return fmt.Errorf("tunnel process stopped: %w", event.Err)
+			}
+			s.pass(ctx, "tunnel process restarted")
+		}
+	}
+}
+
+// healLoop reconciles about every watch interval until ctx is done.
+func (s *Service) healLoop(ctx context.Context) {
+	logger.Infof("Watching tunnel resources", map[string]any{
+		"interval": s.watchInterval.String(),
+	})
+
+	for {
+		timer := time.NewTimer(jitter(s.watchInterval))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.pass(ctx, "periodic")
+	}
+}
+
+// pass runs one reconciliation unless the service is stopping. Failures are
+// logged and retried by the next pass.
+func (s *Service) pass(ctx context.Context, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		return
+	}
+
+	logger.Debugf("Running reconcile pass", map[string]any{"reason": reason})
+	if err := s.reconcile(ctx); err != nil {
+		logger.Warnf("Reconcile pass failed", map[string]any{
+			"reason": reason,
+			"error":  err.Error(),
+		})
+	}
+}
+
+// reload switches the desired state to cfg and reconciles. The tunnel name and
+// zones identify the running tunnel and account, so changing them needs a restart.
+func (s *Service) reload(ctx context.Context, cfg Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		logger.Debug("Ignoring configuration change after stop")
+		return
+	}
+
+	if cfg.Tunnel.Ref() != s.tunnel.Ref() || !slices.Equal(cfg.Ingress.Zones(), s.ingress.Zones()) {
+		logger.Warnf("Tunnel name or zones changed, restart to apply", map[string]any{
+			"tunnel": cfg.Tunnel.Ref(),
+			"zones":  cfg.Ingress.Zones(),
+		})
+		return
+	}
+
+	logger.Info("Configuration changed, reconciling")
+
+	s.tunnel, s.ingress, s.access = cfg.Tunnel, cfg.Ingress, cfg.Access
+
+	if err := s.reconcile(ctx); err != nil {
+		logger.Warnf("Failed to apply configuration change", map[string]any{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Configuration change applied")
+}
+
+// jitter spreads passes by ±10% so several tunnels do not hit the API in lockstep.
+func jitter(interval time.Duration) time.Duration {
+	spread := int64(interval / 5)
+	if spread <= 0 {
+		return interval
+	}
+	return interval - interval/10 + time.Duration(rand.Int64N(spread))
 }
 
 func (s *Service) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped = true
+
 	logger.Infof("Stopping tunnel service", map[string]any{
 		"zone":   s.ingress.Zone,
 		"tunnel": s.tunnel.Ref(),
@@ -89,3 +329,106 @@ func (s *Service) Stop(ctx context.Context) error {
 	logger.Info("Tunnel service stopped")
 	return nil
 }
+
+// Plan reports the changes Start would apply against the real account and lock file.
+func (s *Service) Plan(ctx context.Context) (*framework.Plan, error) {
+	logger.Infof("Planning tunnel service", map[string]any{
+		"zone":   s.ingress.Zone,
+		"tunnel": s.tunnel.Ref(),
+	})
+
+	// Plan never saves, so a shared lock is enough and a running Start is not blocked.
+	orch, err := s.createOrchestrator(ctx, framework.WithReadOnly())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create orchestrator: %w", err)
+	}
+
+	plan, err := orch.Plan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan resources: %w", err)
+	}
+
+	return plan, nil
+}
+
+// Drift compares the live Cloudflare state of tracked resources with the lock file.
+// With repair, drifted entries are rewritten so the next Start restores them.
+func (s *Service) Drift(ctx context.Context, repair bool) (*framework.DriftReport, error) {
+	logger.Infof("Detecting drift", map[string]any{
+		"zone":   s.ingress.Zone,
+		"tunnel": s.tunnel.Ref(),
+		"repair": repair,
+	})
+
+	var opts []framework.ReconcilerOption
+	if !repair {
+		opts = append(opts, framework.WithReadOnly())
+	}
+
+	orch, err := s.createOrchestrator(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create orchestrator: %w", err)
+	}
+
+	report, err := orch.Drift(ctx, repair)
+	if err != nil {
+		return report, fmt.Errorf("failed to detect drift: %w", err)
+	}
+
+	return report, nil
+}
+
+// Status checks every resource tracked in the lock file without changing anything.
+func (s *Service) Status(ctx context.Context) (*framework.StatusReport, error) {
+	logger.Infof("Checking tunnel status", map[string]any{
+		"zone":   s.ingress.Zone,
+		"tunnel": s.tunnel.Ref(),
+	})
+
+	orch, err := s.createOrchestrator(ctx, framework.WithReadOnly())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create orchestrator: %w", err)
+	}
+
+	report, err := orch.Status(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check resources: %w", err)
+	}
+
+	return report, nil
+}
+
+// Entries lists the resources tracked in the lock file without checking them.
+func (s *Service) Entries(ctx context.Context) ([]framework.LockEntry, error) {
+	orch, err := s.createOrchestrator(ctx, framework.WithReadOnly())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create orchestrator: %w", err)
+	}
+	return orch.Entries()
+}
+
+// Forget stops tracking a resource without destroying it.
+func (s *Service) Forget(ctx context.Context, handlerName, key string) (framework.LockEntry, error) {
+	orch, err := s.createOrchestrator(ctx)
+	if err != nil {
+		return framework.LockEntry{}, fmt.Errorf("failed to create orchestrator: %w", err)
+	}
+	return orch.Forget(handlerName, key)
+}
+
+// Import adopts an existing resource into the lock file, by provider ID when
+// id is set and by name otherwise.
+func (s *Service) Import(ctx context.Context, handlerName, key, id string) (framework.LockEntry, error) {
+	logger.Infof("Importing resource", map[string]any{
+		"zone":    s.ingress.Zone,
+		"tunnel":  s.tunnel.Ref(),
+		"handler": handlerName,
+		"key":     key,
+	})
+
+	orch, err := s.createOrchestrator(ctx)
+	if err != nil {
+		return framework.LockEntry{}, fmt.Errorf("failed to create orchestrator: %w", err)
+	}
+	return orch.Import(ctx, handlerName, key, id)
+}
@@ -0,0 +1,280 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stupside/moley/v2/internal/domain"
+	accessusecase "github.com/stupside/moley/v2/internal/features/access/usecase"
+	dnsusecase "github.com/stupside/moley/v2/internal/features/dns/usecase"
+	tunnelusecase "github.com/stupside/moley/v2/internal/features/tunnel/usecase"
+	framework "github.com/stupside/moley/v2/internal/platform/orchestration"
+)
+
+// --- In-memory Cloudflare fakes ---
+
+// fakeTunnel creates tunnels, writes configs to dir, and runs no process.
+type fakeTunnel struct {
+	dir string
+}
+
+func (f *fakeTunnel) Create(context.Context, *domain.Tunnel) (string, error) { return "uuid", nil }
+func (f *fakeTunnel) Delete(context.Context, *domain.Tunnel) error           { return nil }
+func (f *fakeTunnel) Exists(context.Context, *domain.Tunnel) (bool, error)   { return true, nil }
+func (f *fakeTunnel) GetID(context.Context, *domain.Tunnel) (string, error)  { return "uuid", nil }
+
+func (f *fakeTunnel) SaveConfiguration(_ context.Context, tunnel *domain.Tunnel, ingress *domain.Ingress) error {
+	return os.WriteFile(filepath.Join(f.dir, tunnel.Name+".yml"), fmt.Appendf(nil, "%d apps", len(ingress.Apps)), 0o600)
+}
+
+func (f *fakeTunnel) DeleteConfiguration(_ context.Context, tunnel *domain.Tunnel) error {
+	return os.Remove(filepath.Join(f.dir, tunnel.Name+".yml"))
+}
+
+func (f *fakeTunnel) GetConfigurationPath(_ context.Context, tunnel *domain.Tunnel) (string, error) {
+	return filepath.Join(f.dir, tunnel.Name+".yml"), nil
+}
+
+// Run returns PID 0, which the run handler treats as a process it does not manage.
+func (f *fakeTunnel) Run(context.Context, *domain.Tunnel) (int, string, error) { return 0, "", nil }
+func (f *fakeTunnel) Stop(context.Context, int) (bool, error)                  { return false, nil }
+func (f *fakeTunnel) Status(int) (tunnelusecase.ProcessStatus, bool) {
+	return tunnelusecase.ProcessStatus{}, false
+}
+func (f *fakeTunnel) Events() <-chan tunnelusecase.ProcessEvent { return nil }
+func (f *fakeTunnel) Connections(context.Context, *domain.Tunnel) (int, error) {
+	return 1, nil
+}
+
+type fakeDNS struct{}
+
+func (fakeDNS) RouteRecord(context.Context, string, string, string, string) error { return nil }
+func (fakeDNS) DeleteRecord(context.Context, string, string, string) error        { return nil }
+func (fakeDNS) RecordExists(context.Context, string, string, string) (bool, error) {
+	return true, nil
+}
+func (fakeDNS) RecordTunnel(context.Context, string, string) (string, bool, error) {
+	return "", false, nil
+}
+func (fakeDNS) RepointRecord(context.Context, string, string, string, string) error { return nil }
+func (fakeDNS) RecordOwned(context.Context, string, string, string) (bool, error) {
+	return true, nil
+}
+func (fakeDNS) RecordID(context.Context, string, string) (string, bool, error) {
+	return "record", true, nil
+}
+
+// fakeAccess keeps Access applications and policies in memory and records deletions.
+type fakeAccess struct {
+	apps            map[string]string // domain -> ID
+	policies        map[string]string // name -> ID
+	deletedApps     []string
+	deletedPolicies []string
+}
+
+func newFakeAccess() *fakeAccess {
+	return &fakeAccess{apps: make(map[string]string), policies: make(map[string]string)}
+}
+
+func (f *fakeAccess) CreateApplication(_ context.Context, params accessusecase.AccessApplicationParams) (string, error) {
+	id := "app-" + params.Domain
+	f.apps[params.Domain] = id
+	return id, nil
+}
+
+func (f *fakeAccess) UpdateApplication(context.Context, string, accessusecase.AccessApplicationParams) error {
+	return nil
+}
+
+func (f *fakeAccess) DeleteApplication(_ context.Context, appID string) error {
+	for domain, id := range f.apps {
+		if id == appID {
+			delete(f.apps, domain)
+		}
+	}
+	f.deletedApps = append(f.deletedApps, appID)
+	return nil
+}
+
+func (f *fakeAccess) GetApplication(context.Context, string) (accessusecase.ObservedApplication, bool, error) {
+	return accessusecase.ObservedApplication{}, true, nil
+}
+
+func (f *fakeAccess) FindApplication(_ context.Context, domain string) (string, bool, error) {
+	id, ok := f.apps[domain]
+	return id, ok, nil
+}
+
+func (f *fakeAccess) ApplicationNamed(context.Context, string, string) (bool, error) {
+	return true, nil
+}
+
+func (f *fakeAccess) CreatePolicy(_ context.Context, policy domain.Policy) (string, error) {
+	id := "policy-" + policy.Name
+	f.policies[policy.Name] = id
+	return id, nil
+}
+
+func (f *fakeAccess) UpdatePolicy(context.Context, string, domain.Policy) error { return nil }
+
+func (f *fakeAccess) DeletePolicy(_ context.Context, policyID string) error {
+	for name, id := range f.policies {
+		if id == policyID {
+			delete(f.policies, name)
+		}
+	}
+	f.deletedPolicies = append(f.deletedPolicies, policyID)
+	return nil
+}
+
+func (f *fakeAccess) GetPolicy(context.Context, string) (map[string]any, bool, error) {
+	return map[string]any{}, true, nil
+}
+
+func (f *fakeAccess) FindPolicy(_ context.Context, name string) (string, bool, error) {
+	id, ok := f.policies[name]
+	return id, ok, nil
+}
+
+// --- Tests ---
+
+func protectedConfig(protected bool) Config {
+	app := domain.AppConfig{
+		Target: domain.TargetConfig{Protocol: domain.ProtocolHTTP, Hostname: "localhost", Port: 8080},
+		Expose: domain.ExposeConfig{Subdomain: "app"},
+	}
+	cfg := Config{
+		Tunnel: &domain.Tunnel{Name: "test"},
+		Ingress: &domain.Ingress{
+			Zone: "example.com",
+			Mode: domain.IngressModeSubdomain,
+			Apps: []domain.AppConfig{app},
+		},
+	}
+	if protected {
+		cfg.Ingress.Apps[0].Access = &domain.AccessConfig{Providers: []string{"github"}}
+		cfg.Ingress.Apps[0].Policies = []string{"admins"}
+		cfg.Access = &domain.Access{Policies: []domain.Policy{{Name: "admins"}}}
+	}
+	return cfg
+}
+
+func TestReloadDestroysLastAccessResources(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	tunnel := &fakeTunnel{dir: dir}
+	access := newFakeAccess()
+
+	cfg := protectedConfig(true)
+	svc := NewService(cfg.Tunnel, cfg.Ingress, cfg.Access, fakeDNS{}, tunnel, tunnel, tunnel, access, access,
+		WithStateBackend(framework.NewFileBackend(filepath.Join(dir, "moley.lock"))),
+	)
+
+	if err := svc.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if len(access.apps) != 1 || len(access.policies) != 1 {
+		t.Fatalf("expected 1 app and 1 policy after start, got %d apps and %d policies", len(access.apps), len(access.policies))
+	}
+
+	svc.reload(ctx, protectedConfig(false))
+
+	if len(access.deletedApps) != 1 || access.deletedApps[0] != "app-app.example.com" {
+		t.Errorf("expected the Access application to be destroyed, got %v", access.deletedApps)
+	}
+	if len(access.deletedPolicies) != 1 || access.deletedPolicies[0] != "policy-moley-admins (tunnel test)" {
+		t.Errorf("expected the policy to be destroyed, got %v", access.deletedPolicies)
+	}
+
+	entries, err := svc.Entries(ctx)
+	if err != nil {
+		t.Fatalf("Entries: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.HandlerName == accessusecase.HandlerName || entry.HandlerName == accessusecase.PolicyHandlerName {
+			t.Errorf("expected no Access entries after reload, found %s %s", entry.HandlerName, entry.Key)
+		}
+	}
+}
+
+func TestPlanTakesSharedLock(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	tunnel := &fakeTunnel{dir: dir}
+	access := newFakeAccess()
+	backend := framework.NewFileBackend(filepath.Join(dir, "moley.lock"))
+
+	cfg := protectedConfig(false)
+	svc := NewService(cfg.Tunnel, cfg.Ingress, cfg.Access, fakeDNS{}, tunnel, tunnel, tunnel, access, access,
+		WithStateBackend(backend),
+		WithLockTimeout(100*time.Millisecond),
+	)
+
+	// Another reader, such as a concurrent tunnel status, holds the lock.
+	unlock, err := backend.Lock(ctx, true)
+	if err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	defer func() { _ = unlock() }()
+
+	if _, err := svc.Plan(ctx); err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "moley.lock.holder")); !os.IsNotExist(err) {
+		t.Errorf("expected Plan not to record a lock holder, got %v", err)
+	}
+}
+
+func TestWildcardRecordPerZone(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	tunnel := &fakeTunnel{dir: dir}
+	access := newFakeAccess()
+
+	app := func(subdomain, zone string) domain.AppConfig {
+		return domain.AppConfig{
+			Target: domain.TargetConfig{Protocol: domain.ProtocolHTTP, Hostname: "localhost", Port: 8080},
+			Expose: domain.ExposeConfig{Subdomain: subdomain, Zone: zone},
+		}
+	}
+	ingress := &domain.Ingress{
+		Zone: "example.com",
+		Mode: domain.IngressModeWildcard,
+		Apps: []domain.AppConfig{
+			app("app", ""),
+			app("api", "example.org"),
+			app("docs", "example.org"),
+			app("www", "example.com"),
+		},
+	}
+	svc := NewService(&domain.Tunnel{Name: "test"}, ingress, nil, fakeDNS{}, tunnel, tunnel, tunnel, access, access,
+		WithStateBackend(framework.NewFileBackend(filepath.Join(dir, "moley.lock"))),
+	)
+
+	if err := svc.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	entries, err := svc.Entries(ctx)
+	if err != nil {
+		t.Fatalf("Entries: %v", err)
+	}
+	var records []string
+	for _, entry := range entries {
+		if entry.HandlerName == dnsusecase.HandlerName {
+			records = append(records, entry.Key)
+		}
+	}
+	slices.Sort(records)
+	if want := []string{"example.com:*", "example.org:*"}; !slices.Equal(records, want) {
+		t.Errorf("expected one wildcard record per zone %v, got %v", want, records)
+	}
+}
@@ -20,6 +20,18 @@ func (p Policy) MarshalJSON() ([]byte, error) {
 	return json.Marshal(m)
 }
 
+// UnmarshalJSON restores Extra from the inlined CF policy body.
+func (p *Policy) UnmarshalJSON(data []byte) error {
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	p.Name, _ = m["name"].(string)
+	delete(m, "name")
+	p.Extra = m
+	return nil
+}
+
 type Access struct {
 	Policies []Policy `yaml:"policies,omitempty"`
 }
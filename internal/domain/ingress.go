@@ -2,9 +2,15 @@
 package domain
 
 import (
+	"cmp"
 	"encoding/json"
 	"fmt"
 	"maps"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strings"
+	"time"
 )
 
 type TargetProtocol string
@@ -13,25 +19,109 @@ const (
 	ProtocolTCP   TargetProtocol = "tcp"
 	ProtocolHTTP  TargetProtocol = "http"
 	ProtocolHTTPS TargetProtocol = "https"
+	ProtocolSSH   TargetProtocol = "ssh"
+	ProtocolRDP   TargetProtocol = "rdp"
+	ProtocolSMB   TargetProtocol = "smb"
+	// ProtocolUnix and ProtocolUnixTLS reach a service listening on a unix socket.
+	ProtocolUnix    TargetProtocol = "unix"
+	ProtocolUnixTLS TargetProtocol = "unix+tls"
+	// ProtocolHelloWorld serves cloudflared's built-in test page.
+	ProtocolHelloWorld TargetProtocol = "hello_world"
+	// ProtocolHTTPStatus answers every request with a fixed status code, e.g. for
+	// a maintenance page.
+	ProtocolHTTPStatus TargetProtocol = "http_status"
 )
 
+// usesAddress reports whether the protocol reaches its target at hostname:port.
+func (p TargetProtocol) usesAddress() bool {
+	switch p {
+	case ProtocolHTTP, ProtocolHTTPS, ProtocolTCP, ProtocolSSH, ProtocolRDP, ProtocolSMB:
+		return true
+	}
+	return false
+}
+
+// NeedsAccessClient reports whether clients reach the protocol through a local
+// `cloudflared access` listener rather than directly over HTTP.
+func (p TargetProtocol) NeedsAccessClient() bool {
+	switch p {
+	case ProtocolTCP, ProtocolSSH, ProtocolRDP, ProtocolSMB:
+		return true
+	}
+	return false
+}
+
 type TargetConfig struct {
-	Port     int            `yaml:"port" json:"port" validate:"required,min=1,max=65535"`
-	Hostname string         `yaml:"hostname" json:"hostname" validate:"required"`
-	Protocol TargetProtocol `yaml:"protocol" json:"protocol" validate:"required,oneof=http https tcp"`
+	Port     int            `yaml:"port,omitempty" json:"port,omitempty" validate:"omitempty,min=1,max=65535"`
+	Hostname string         `yaml:"hostname,omitempty" json:"hostname,omitempty"`
+	Protocol TargetProtocol `yaml:"protocol" json:"protocol" validate:"required,oneof=http https tcp ssh rdp smb unix unix+tls hello_world http_status"`
+	// Socket is the unix socket path of the unix and unix+tls protocols.
+	Socket string `yaml:"socket,omitempty" json:"socket,omitempty"`
+	// Status is the response code of the http_status protocol.
+	Status int `yaml:"status,omitempty" json:"status,omitempty" validate:"omitempty,min=100,max=599"`
 }
 
+// Validate checks that the target sets exactly the fields its protocol uses.
+func (t *TargetConfig) Validate() error {
+	address := t.Protocol.usesAddress()
+	socket := t.Protocol == ProtocolUnix || t.Protocol == ProtocolUnixTLS
+	status := t.Protocol == ProtocolHTTPStatus
+
+	for _, field := range []struct {
+		name string
+		set  bool
+		used bool
+	}{
+		{"hostname", t.Hostname != "", address},
+		{"port", t.Port != 0, address},
+		{"socket", t.Socket != "", socket},
+		{"status", t.Status != 0, status},
+	} {
+		switch {
+		case field.used && !field.set:
+			return fmt.Errorf("protocol %s requires %s", t.Protocol, field.name)
+		case !field.used && field.set:
+			return fmt.Errorf("protocol %s does not use %s", t.Protocol, field.name)
+		}
+	}
+	return nil
+}
+
+// GetTargetURL returns the target in the service format of cloudflared ingress rules.
 func (t *TargetConfig) GetTargetURL() string {
+	switch t.Protocol {
+	case ProtocolUnix, ProtocolUnixTLS:
+		return fmt.Sprintf("%s:%s", t.Protocol, t.Socket)
+	case ProtocolHTTPStatus:
+		return fmt.Sprintf("%s:%d", t.Protocol, t.Status)
+	case ProtocolHelloWorld:
+		return string(t.Protocol)
+	}
 	return fmt.Sprintf("%s://%s:%d", t.Protocol, t.Hostname, t.Port)
 }
 
 type AppConfig struct {
 	Target   TargetConfig  `yaml:"target" json:"target" validate:"required"`
 	Expose   ExposeConfig  `yaml:"expose" json:"expose" validate:"required"`
+	Origin   *OriginConfig `yaml:"origin,omitempty" json:"origin,omitempty" validate:"omitempty"`
 	Access   *AccessConfig `yaml:"access,omitempty" json:"access,omitempty" validate:"omitempty"`
 	Policies []string      `yaml:"policies,omitempty" json:"policies,omitempty"`
 }
 
+// OriginConfig holds the cloudflared originRequest settings used to connect to a
+// local service. Unset fields keep the tunnel-level default, then cloudflared's;
+// booleans are pointers so an app can turn off a setting the default turns on.
+type OriginConfig struct {
+	NoTLSVerify            *bool         `yaml:"no_tls_verify,omitempty" json:"no_tls_verify,omitempty"`
+	OriginServerName       string        `yaml:"origin_server_name,omitempty" json:"origin_server_name,omitempty" validate:"omitempty,hostname_rfc1123"`
+	CAPool                 string        `yaml:"ca_pool,omitempty" json:"ca_pool,omitempty" validate:"omitempty,file"`
+	HTTPHostHeader         string        `yaml:"http_host_header,omitempty" json:"http_host_header,omitempty" validate:"omitempty,hostname_port|hostname_rfc1123"`
+	ConnectTimeout         time.Duration `yaml:"connect_timeout,omitempty" json:"connect_timeout,omitempty" validate:"gte=0"`
+	KeepAliveTimeout       time.Duration `yaml:"keep_alive_timeout,omitempty" json:"keep_alive_timeout,omitempty" validate:"gte=0"`
+	HTTP2Origin            *bool         `yaml:"http2_origin,omitempty" json:"http2_origin,omitempty"`
+	DisableChunkedEncoding *bool         `yaml:"disable_chunked_encoding,omitempty" json:"disable_chunked_encoding,omitempty"`
+}
+
 // AccessConfig holds the raw Cloudflare Access application configuration.
 // Fields match the CF API shape and are passed through with minimal transformation.
 // Only `providers` is processed by Moley (resolved to IdP UUIDs); everything else
@@ -51,8 +141,73 @@ func (a AccessConfig) MarshalJSON() ([]byte, error) {
 	return json.Marshal(m)
 }
 
+// UnmarshalJSON splits providers from the inlined Raw fields, so a recorded
+// config decodes back to the value it was encoded from.
+func (a *AccessConfig) UnmarshalJSON(data []byte) error {
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	a.Providers = nil
+	if providers, ok := m["providers"].([]any); ok {
+		for _, p := range providers {
+			if s, ok := p.(string); ok {
+				a.Providers = append(a.Providers, s)
+			}
+		}
+	}
+	delete(m, "providers")
+	a.Raw = m
+	return nil
+}
+
 type ExposeConfig struct {
 	Subdomain string `yaml:"subdomain" json:"subdomain" validate:"required"`
+	// Zone is the DNS zone of the hostname when it is not the ingress zone. All
+	// zones of a tunnel must belong to the same Cloudflare account.
+	Zone string `yaml:"zone,omitempty" json:"zone,omitempty" validate:"omitempty,fqdn"`
+	// Path is a regular expression matched against the request path, as in
+	// cloudflared ingress rules. Empty matches every path on the hostname.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+}
+
+// AccessPath returns the path an Access application must cover to protect every
+// request Path matches: its literal prefix, with a trailing wildcard unless the
+// regex is that literal anchored at both ends. Access cannot express unanchored
+// regexes, so Path must start with "^/".
+func (e ExposeConfig) AccessPath() (string, error) {
+	if e.Path == "" {
+		return "", nil
+	}
+
+	re, err := syntax.Parse(e.Path, syntax.Perl)
+	if err != nil {
+		return "", fmt.Errorf("invalid path regex %q: %w", e.Path, err)
+	}
+	re = re.Simplify()
+
+	subs := []*syntax.Regexp{re}
+	if re.Op == syntax.OpConcat {
+		subs = re.Sub
+	}
+	if subs[0].Op != syntax.OpBeginText {
+		return "", fmt.Errorf("path %q must start with ^/ to be protected by Access", e.Path)
+	}
+
+	var prefix strings.Builder
+	rest := subs[1:]
+	for len(rest) > 0 && rest[0].Op == syntax.OpLiteral && rest[0].Flags&syntax.FoldCase == 0 {
+		prefix.WriteString(string(rest[0].Rune))
+		rest = rest[1:]
+	}
+	if !strings.HasPrefix(prefix.String(), "/") {
+		return "", fmt.Errorf("path %q must start with ^/ to be protected by Access", e.Path)
+	}
+
+	if len(rest) == 1 && rest[0].Op == syntax.OpEndText {
+		return prefix.String(), nil
+	}
+	return prefix.String() + "*", nil
 }
 
 type IngressMode string
@@ -68,6 +223,70 @@ type Ingress struct {
 	Zone string      `yaml:"zone" json:"zone" validate:"required"`
 	Apps []AppConfig `yaml:"apps" json:"apps" validate:"required,dive"`
 	Mode IngressMode `yaml:"mode" json:"mode" validate:"required,oneof=wildcard subdomain"`
+	// Origin holds the originRequest defaults of every app; see AppConfig.Origin.
+	Origin *OriginConfig `yaml:"origin,omitempty" json:"origin,omitempty" validate:"omitempty"`
+}
+
+// Validate checks the rules of every app that struct tags cannot express.
+func (i *Ingress) Validate() error {
+	for idx, app := range i.Apps {
+		if err := app.Target.Validate(); err != nil {
+			return fmt.Errorf("ingress.apps[%d].target: %w", idx, err)
+		}
+		if err := app.validatePath(); err != nil {
+			return fmt.Errorf("ingress.apps[%d].expose: %w", idx, err)
+		}
+	}
+	return nil
+}
+
+// validatePath checks that the path is a valid regex and, when the app is
+// protected by Access, that Access can cover it.
+func (a AppConfig) validatePath() error {
+	if a.Expose.Path == "" {
+		return nil
+	}
+	if _, err := regexp.Compile(a.Expose.Path); err != nil {
+		return fmt.Errorf("invalid path regex %q: %w", a.Expose.Path, err)
+	}
+	if a.Access != nil {
+		if _, err := a.Expose.AccessPath(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AppZone returns the zone app is exposed in: its own, or else the ingress zone.
+func (i *Ingress) AppZone(app AppConfig) string {
+	return cmp.Or(app.Expose.Zone, i.Zone)
+}
+
+// Hostname returns the hostname app is exposed on.
+func (i *Ingress) Hostname(app AppConfig) string {
+	return FQDN(app.Expose.Subdomain, i.AppZone(app))
+}
+
+// Zones returns every zone apps are exposed in, starting with the ingress zone.
+func (i *Ingress) Zones() []string {
+	zones := []string{i.Zone}
+	for _, app := range i.Apps {
+		if zone := i.AppZone(app); !slices.Contains(zones, zone) {
+			zones = append(zones, zone)
+		}
+	}
+	return zones
+}
+
+// FindApp returns the first app exposed on name, either a hostname or a
+// subdomain of the ingress zone.
+func (i *Ingress) FindApp(name string) (AppConfig, bool) {
+	for _, app := range i.Apps {
+		if hostname := i.Hostname(app); hostname == name || hostname == FQDN(name, i.Zone) {
+			return app, true
+		}
+	}
+	return AppConfig{}, false
 }
 
 func (i *Ingress) HasAccessConfig() bool {
@@ -0,0 +1,160 @@
+package domain_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stupside/moley/v2/internal/domain"
+)
+
+func TestExposeConfigAccessPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "", want: ""},
+		{path: "^/api", want: "/api*"},
+		{path: "^/api$", want: "/api"},
+		{path: "^/api/.*", want: "/api/*"},
+		{path: "^/api/v1/users$", want: "/api/v1/users"},
+		{path: "^/(a|b)", want: "/*"},
+		{path: "(?i)^/x", wantErr: true},
+		{path: "/api", wantErr: true},
+		{path: "^api", wantErr: true},
+		{path: "^/(api", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := domain.ExposeConfig{Subdomain: "app", Path: tt.path}.AccessPath()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestIngressValidatePath(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		access  bool
+		wantErr bool
+	}{
+		{name: "no path", access: true},
+		{name: "anchored", path: "^/api/", access: true},
+		{name: "unanchored without access", path: "/api"},
+		{name: "unanchored with access", path: "/api", access: true, wantErr: true},
+		{name: "case-insensitive with access", path: "(?i)^/x", access: true, wantErr: true},
+		{name: "invalid regex", path: "^/(api", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := domain.AppConfig{
+				Target: domain.TargetConfig{Protocol: domain.ProtocolHelloWorld},
+				Expose: domain.ExposeConfig{Subdomain: "app", Path: tt.path},
+			}
+			if tt.access {
+				app.Access = &domain.AccessConfig{}
+			}
+			ingress := &domain.Ingress{Zone: "example.com", Mode: domain.IngressModeSubdomain, Apps: []domain.AppConfig{app}}
+
+			err := ingress.Validate()
+			if tt.wantErr && err == nil {
+				t.Error("expected an error")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTargetConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  domain.TargetConfig
+		wantURL string
+		wantErr bool
+	}{
+		{name: "http", target: domain.TargetConfig{Protocol: domain.ProtocolHTTP, Hostname: "localhost", Port: 3000}, wantURL: "http://localhost:3000"},
+		{name: "https", target: domain.TargetConfig{Protocol: domain.ProtocolHTTPS, Hostname: "localhost", Port: 8443}, wantURL: "https://localhost:8443"},
+		{name: "tcp", target: domain.TargetConfig{Protocol: domain.ProtocolTCP, Hostname: "db", Port: 5432}, wantURL: "tcp://db:5432"},
+		{name: "ssh", target: domain.TargetConfig{Protocol: domain.ProtocolSSH, Hostname: "localhost", Port: 22}, wantURL: "ssh://localhost:22"},
+		{name: "rdp", target: domain.TargetConfig{Protocol: domain.ProtocolRDP, Hostname: "desktop", Port: 3389}, wantURL: "rdp://desktop:3389"},
+		{name: "smb", target: domain.TargetConfig{Protocol: domain.ProtocolSMB, Hostname: "nas", Port: 445}, wantURL: "smb://nas:445"},
+		{name: "unix", target: domain.TargetConfig{Protocol: domain.ProtocolUnix, Socket: "/run/app.sock"}, wantURL: "unix:/run/app.sock"},
+		{name: "unix+tls", target: domain.TargetConfig{Protocol: domain.ProtocolUnixTLS, Socket: "/run/app.sock"}, wantURL: "unix+tls:/run/app.sock"},
+		{name: "http_status", target: domain.TargetConfig{Protocol: domain.ProtocolHTTPStatus, Status: 503}, wantURL: "http_status:503"},
+		{name: "hello_world", target: domain.TargetConfig{Protocol: domain.ProtocolHelloWorld}, wantURL: "hello_world"},
+
+		{name: "http without hostname", target: domain.TargetConfig{Protocol: domain.ProtocolHTTP, Port: 3000}, wantErr: true},
+		{name: "tcp without port", target: domain.TargetConfig{Protocol: domain.ProtocolTCP, Hostname: "db"}, wantErr: true},
+		{name: "ssh with socket", target: domain.TargetConfig{Protocol: domain.ProtocolSSH, Hostname: "localhost", Port: 22, Socket: "/run/ssh.sock"}, wantErr: true},
+		{name: "unix without socket", target: domain.TargetConfig{Protocol: domain.ProtocolUnix}, wantErr: true},
+		{name: "unix with port", target: domain.TargetConfig{Protocol: domain.ProtocolUnix, Socket: "/run/app.sock", Port: 80}, wantErr: true},
+		{name: "unix+tls with hostname", target: domain.TargetConfig{Protocol: domain.ProtocolUnixTLS, Socket: "/run/app.sock", Hostname: "localhost"}, wantErr: true},
+		{name: "http_status without status", target: domain.TargetConfig{Protocol: domain.ProtocolHTTPStatus}, wantErr: true},
+		{name: "http_status with port", target: domain.TargetConfig{Protocol: domain.ProtocolHTTPStatus, Status: 404, Port: 80}, wantErr: true},
+		{name: "hello_world with port", target: domain.TargetConfig{Protocol: domain.ProtocolHelloWorld, Port: 80}, wantErr: true},
+		{name: "https with status", target: domain.TargetConfig{Protocol: domain.ProtocolHTTPS, Hostname: "localhost", Port: 443, Status: 200}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.target.Validate()
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if got := tt.target.GetTargetURL(); got != tt.wantURL {
+				t.Errorf("expected %q, got %q", tt.wantURL, got)
+			}
+		})
+	}
+}
+
+func TestIngressZones(t *testing.T) {
+	app := func(zone string) domain.AppConfig {
+		return domain.AppConfig{Expose: domain.ExposeConfig{Subdomain: "app", Zone: zone}}
+	}
+	tests := []struct {
+		name string
+		apps []domain.AppConfig
+		want []string
+	}{
+		{name: "ingress zone only", apps: []domain.AppConfig{app(""), app("")}, want: []string{"example.com"}},
+		{name: "ingress zone named by an app", apps: []domain.AppConfig{app("example.com")}, want: []string{"example.com"}},
+		{name: "no apps", want: []string{"example.com"}},
+		{
+			name: "deduplicated in order",
+			apps: []domain.AppConfig{app("example.org"), app(""), app("example.net"), app("example.org")},
+			want: []string{"example.com", "example.org", "example.net"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ingress := &domain.Ingress{Zone: "example.com", Apps: tt.apps}
+			if got := ingress.Zones(); !slices.Equal(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -1,10 +1,14 @@
 package cloudflare
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"maps"
+	"net/http"
+	"slices"
 
 	cfgo "github.com/cloudflare/cloudflare-go/v3"
 	"github.com/cloudflare/cloudflare-go/v3/zero_trust"
@@ -62,6 +66,47 @@ func (s *AccessService) CreateApplication(ctx context.Context, params accessusec
 		return "dry-run-access-app", nil
 	}
 
+	body, err := s.applicationBody(ctx, params)
+	if err != nil {
+		return "", err
+	}
+
+	var env struct {
+		Result struct {
+			ID string `json:"id"`
+		} `json:"result"`
+	}
+	if err := s.client.Post(ctx, s.appsPath(), body, &env); err != nil {
+		return "", fmt.Errorf("failed to create Access Application: %w", err)
+	}
+
+	logger.Debugf("Access Application created", map[string]any{"app_id": env.Result.ID, "domain": params.Domain})
+	return env.Result.ID, nil
+}
+
+// UpdateApplication replaces the configuration of an existing Access Application,
+// keeping its ID so dashboard links and audit history stay intact.
+func (s *AccessService) UpdateApplication(ctx context.Context, appID string, params accessusecase.AccessApplicationParams) error {
+	if s.dryRun {
+		logger.Debug("Dry run: skipping Access Application update")
+		return nil
+	}
+
+	body, err := s.applicationBody(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	if err := s.client.Put(ctx, s.appsPath()+"/"+appID, body, nil); err != nil {
+		return fmt.Errorf("failed to update Access Application %s: %w", appID, err)
+	}
+
+	logger.Debugf("Access Application updated", map[string]any{"app_id": appID, "domain": params.Domain})
+	return nil
+}
+
+// applicationBody builds the request body shared by create and update calls.
+func (s *AccessService) applicationBody(ctx context.Context, params accessusecase.AccessApplicationParams) (accessAppBody, error) {
 	body := accessAppBody{
 		Name:   params.Name,
 		Domain: params.Domain,
@@ -72,7 +117,7 @@ func (s *AccessService) CreateApplication(ctx context.Context, params accessusec
 	if len(params.Access.Providers) > 0 {
 		ids, err := s.resolveIdentityProviders(ctx, params.Access.Providers)
 		if err != nil {
-			return "", fmt.Errorf("failed to resolve identity providers: %w", err)
+			return accessAppBody{}, fmt.Errorf("failed to resolve identity providers: %w", err)
 		}
 		body.AllowedIdPs = ids
 	}
@@ -84,17 +129,7 @@ func (s *AccessService) CreateApplication(ctx context.Context, params accessusec
 		}
 	}
 
-	var env struct {
-		Result struct {
-			ID string `json:"id"`
-		} `json:"result"`
-	}
-	if err := s.client.Post(ctx, s.appsPath(), body, &env); err != nil {
-		return "", fmt.Errorf("failed to create Access Application: %w", err)
-	}
-
-	logger.Debugf("Access Application created", map[string]any{"app_id": env.Result.ID, "domain": params.Domain})
-	return env.Result.ID, nil
+	return body, nil
 }
 
 func (s *AccessService) DeleteApplication(ctx context.Context, appID string) error {
@@ -129,6 +164,96 @@ func (s *AccessService) FindApplication(ctx context.Context, domain string) (str
 	return "", false, nil
 }
 
+// ApplicationNamed reports whether the Access Application is named name.
+func (s *AccessService) ApplicationNamed(ctx context.Context, appID string, name string) (bool, error) {
+	if s.dryRun {
+		return true, nil
+	}
+	var env struct {
+		Result struct {
+			Name string `json:"name"`
+		} `json:"result"`
+	}
+	if err := s.client.Get(ctx, s.appsPath()+"/"+appID, nil, &env); err != nil {
+		return false, fmt.Errorf("failed to get Access Application %s: %w", appID, err)
+	}
+	return env.Result.Name == name, nil
+}
+
+// GetApplication reads the live configuration of an Access Application.
+func (s *AccessService) GetApplication(ctx context.Context, appID string) (accessusecase.ObservedApplication, bool, error) {
+	if s.dryRun {
+		return accessusecase.ObservedApplication{}, true, nil
+	}
+	var env struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := s.client.Get(ctx, s.appsPath()+"/"+appID, nil, &env); err != nil {
+		if isNotFound(err) {
+			return accessusecase.ObservedApplication{}, false, nil
+		}
+		return accessusecase.ObservedApplication{}, false, fmt.Errorf("failed to get Access Application %s: %w", appID, err)
+	}
+
+	var app accessusecase.ObservedApplication
+	if err := json.Unmarshal(env.Result, &app.Fields); err != nil {
+		return accessusecase.ObservedApplication{}, false, fmt.Errorf("failed to decode Access Application %s: %w", appID, err)
+	}
+
+	var refs struct {
+		AllowedIdPs []string    `json:"allowed_idps"`
+		Policies    []policyRef `json:"policies"`
+	}
+	if err := json.Unmarshal(env.Result, &refs); err != nil {
+		return accessusecase.ObservedApplication{}, false, fmt.Errorf("failed to decode Access Application %s: %w", appID, err)
+	}
+
+	slices.SortStableFunc(refs.Policies, func(a, b policyRef) int {
+		return cmp.Compare(a.Precedence, b.Precedence)
+	})
+	for _, p := range refs.Policies {
+		app.PolicyIDs = append(app.PolicyIDs, p.ID)
+	}
+
+	if len(refs.AllowedIdPs) > 0 {
+		types, err := s.identityProviderTypes(ctx, refs.AllowedIdPs)
+		if err != nil {
+			return accessusecase.ObservedApplication{}, false, err
+		}
+		app.Providers = types
+	}
+
+	return app, true, nil
+}
+
+// identityProviderTypes maps IdP UUIDs back to the provider types used in moley.yml.
+// UUIDs that are not on the account are returned unchanged.
+func (s *AccessService) identityProviderTypes(ctx context.Context, ids []string) ([]string, error) {
+	pager := s.client.ZeroTrust.IdentityProviders.ListAutoPaging(ctx, zero_trust.IdentityProviderListParams{
+		AccountID: cfgo.F(s.accountID),
+	})
+
+	typeByID := make(map[string]string)
+	for pager.Next() {
+		idp := pager.Current()
+		typeByID[idp.ID] = string(idp.Type)
+	}
+	if err := pager.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list identity providers: %w", err)
+	}
+
+	types := make([]string, len(ids))
+	for i, id := range ids {
+		types[i] = cmp.Or(typeByID[id], id)
+	}
+	return types, nil
+}
+
+func isNotFound(err error) bool {
+	var apiErr *cfgo.Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
 func (s *AccessService) resolveIdentityProviders(ctx context.Context, types []string) ([]string, error) {
 	want := make(map[string]struct{}, len(types))
 	for _, t := range types {
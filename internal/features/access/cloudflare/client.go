@@ -0,0 +1,65 @@
+package cloudflare
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	logger "github.com/stupside/moley/v2/internal/platform/logging"
+)
+
+// ClientParams describes the client side of a TCP service behind an Access application.
+type ClientParams struct {
+	// Hostname is the public hostname of the application.
+	Hostname string
+	// Listen is the local address to accept connections on. When empty, a single
+	// connection is carried over stdin and stdout, e.g. as an SSH ProxyCommand.
+	Listen string
+	// ServiceTokenID and ServiceTokenSecret authenticate to applications gated by
+	// a service token instead of a browser login.
+	ServiceTokenID     string
+	ServiceTokenSecret string
+}
+
+// ForwardTCP runs `cloudflared access tcp` in the foreground until it exits or
+// ctx is done.
+func ForwardTCP(ctx context.Context, params ClientParams) error {
+	cmd := accessTCPCommand(ctx, params)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	logger.Debugf("Starting cloudflared access client", map[string]any{
+		"args": strings.Join(cmd.Args[1:], " "),
+	})
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("cloudflared access tcp failed: %w", err)
+	}
+	return nil
+}
+
+// accessTCPCommand builds the `cloudflared access tcp` command for params.
+func accessTCPCommand(ctx context.Context, params ClientParams) *exec.Cmd {
+	args := []string{"access", "tcp", "--hostname", params.Hostname}
+	if params.Listen != "" {
+		args = append(args, "--url", params.Listen)
+	}
+
+	cmd := exec.CommandContext(ctx, "cloudflared", args...)
+
+	// Passed through the environment so the secret does not show up in ps.
+	cmd.Env = os.Environ()
+	if params.ServiceTokenID != "" {
+		cmd.Env = append(cmd.Env,
+			"TUNNEL_SERVICE_TOKEN_ID="+params.ServiceTokenID,
+			"TUNNEL_SERVICE_TOKEN_SECRET="+params.ServiceTokenSecret,
+		)
+	}
+	return cmd
+}
@@ -0,0 +1,64 @@
+package cloudflare
+
+import (
+	"context"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAccessTCPCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		params   ClientParams
+		wantArgs []string
+		wantEnv  []string
+	}{
+		{
+			name:     "stdio",
+			params:   ClientParams{Hostname: "ssh.example.com"},
+			wantArgs: []string{"cloudflared", "access", "tcp", "--hostname", "ssh.example.com"},
+		},
+		{
+			name:     "listener",
+			params:   ClientParams{Hostname: "db.example.com", Listen: "localhost:5432"},
+			wantArgs: []string{"cloudflared", "access", "tcp", "--hostname", "db.example.com", "--url", "localhost:5432"},
+		},
+		{
+			name: "service token",
+			params: ClientParams{
+				Hostname:           "db.example.com",
+				Listen:             "localhost:5432",
+				ServiceTokenID:     "token-id.access",
+				ServiceTokenSecret: "token-secret",
+			},
+			wantArgs: []string{"cloudflared", "access", "tcp", "--hostname", "db.example.com", "--url", "localhost:5432"},
+			wantEnv:  []string{"TUNNEL_SERVICE_TOKEN_ID=token-id.access", "TUNNEL_SERVICE_TOKEN_SECRET=token-secret"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := accessTCPCommand(context.Background(), tt.params)
+
+			if !slices.Equal(cmd.Args, tt.wantArgs) {
+				t.Errorf("unexpected args\n got: %v\nwant: %v", cmd.Args, tt.wantArgs)
+			}
+			for _, kv := range tt.wantEnv {
+				if !slices.Contains(cmd.Env, kv) {
+					t.Errorf("expected %s in the environment", kv)
+				}
+			}
+			for _, arg := range cmd.Args {
+				if tt.params.ServiceTokenID != "" && strings.Contains(arg, tt.params.ServiceTokenID) ||
+					tt.params.ServiceTokenSecret != "" && strings.Contains(arg, tt.params.ServiceTokenSecret) {
+					t.Errorf("service token credentials must not be passed as arguments, got %q", arg)
+				}
+			}
+			if tt.params.ServiceTokenID == "" && !slices.Equal(cmd.Env, os.Environ()) {
+				t.Error("expected the environment to be inherited unchanged without a service token")
+			}
+		})
+	}
+}
@@ -26,6 +26,34 @@ func (s *AccessService) CreatePolicy(ctx context.Context, policy domain.Policy)
 	return env.Result.ID, nil
 }
 
+// UpdatePolicy replaces the rules of an existing reusable policy, keeping its ID.
+func (s *AccessService) UpdatePolicy(ctx context.Context, policyID string, policy domain.Policy) error {
+	if s.dryRun {
+		return nil
+	}
+	if err := s.client.Put(ctx, s.policiesPath()+"/"+policyID, policy, nil); err != nil {
+		return fmt.Errorf("failed to update policy %s: %w", policyID, err)
+	}
+	return nil
+}
+
+// GetPolicy reads the live body of a reusable policy.
+func (s *AccessService) GetPolicy(ctx context.Context, policyID string) (map[string]any, bool, error) {
+	if s.dryRun {
+		return map[string]any{}, true, nil
+	}
+	var env struct {
+		Result map[string]any `json:"result"`
+	}
+	if err := s.client.Get(ctx, s.policiesPath()+"/"+policyID, nil, &env); err != nil {
+		if isNotFound(err) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to get policy %s: %w", policyID, err)
+	}
+	return env.Result, true, nil
+}
+
 func (s *AccessService) DeletePolicy(ctx context.Context, policyID string) error {
 	if s.dryRun {
 		return nil
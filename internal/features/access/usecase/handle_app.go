@@ -4,6 +4,7 @@ package access
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/stupside/moley/v2/internal/domain"
 	logger "github.com/stupside/moley/v2/internal/platform/logging"
@@ -17,10 +18,21 @@ type AccessApplicationParams struct {
 	PolicyIDs []string
 }
 
+// ObservedApplication is the live configuration of an Access Application.
+// Providers holds IdP types rather than UUIDs, and Fields the raw CF API body.
+type ObservedApplication struct {
+	Providers []string
+	PolicyIDs []string
+	Fields    map[string]any
+}
+
 type AccessManager interface {
 	CreateApplication(ctx context.Context, params AccessApplicationParams) (string, error)
+	UpdateApplication(ctx context.Context, appID string, params AccessApplicationParams) error
 	DeleteApplication(ctx context.Context, appID string) error
+	GetApplication(ctx context.Context, appID string) (ObservedApplication, bool, error)
 	FindApplication(ctx context.Context, domain string) (string, bool, error)
+	ApplicationNamed(ctx context.Context, appID string, name string) (bool, error)
 }
 
 const HandlerName = "access-app"
@@ -28,21 +40,38 @@ const HandlerName = "access-app"
 type AppInput struct {
 	Zone      string              `json:"zone"`
 	Subdomain string              `json:"subdomain"`
+	Path      string              `json:"path,omitempty"`
 	Access    domain.AccessConfig `json:"access"`
 	PolicyIDs []string            `json:"policy_ids,omitempty"`
+	// TunnelName only marks ownership in the application name, so it is kept
+	// out of the recorded input and its hash.
+	TunnelName string `json:"-"`
 }
 
 type AppOutput struct {
 	Zone      string `json:"zone"`
 	AppID     string `json:"app_id"`
 	Subdomain string `json:"subdomain"`
+	Path      string `json:"path,omitempty"`
+}
+
+// applicationDomain is the Access application domain: the hostname, followed by the
+// path it covers when the app is routed by path.
+func applicationDomain(zone, subdomain, path string) string {
+	return domain.FQDN(subdomain, zone) + path
 }
 
 type appHandler struct {
 	accessService AccessManager
 }
 
-var _ framework.Lifecycle[AppInput, AppOutput] = (*appHandler)(nil)
+var (
+	_ framework.Lifecycle[AppInput, AppOutput] = (*appHandler)(nil)
+	_ framework.Updater[AppInput, AppOutput]   = (*appHandler)(nil)
+	_ framework.Observer[AppInput, AppOutput]  = (*appHandler)(nil)
+	_ framework.Describer[AppInput, AppOutput] = (*appHandler)(nil)
+	_ framework.Identifier[AppOutput]          = (*appHandler)(nil)
+)
 
 func NewHandler(accessService AccessManager) *appHandler {
 	return &appHandler{accessService: accessService}
@@ -53,44 +82,103 @@ func (h *appHandler) Name() string {
 }
 
 func (h *appHandler) Key(input AppInput) string {
-	return fmt.Sprintf("%s:%s", input.Zone, input.Subdomain)
+	return fmt.Sprintf("%s:%s%s", input.Zone, input.Subdomain, input.Path)
 }
 
 func (h *appHandler) Create(ctx context.Context, input AppInput) (AppOutput, error) {
-	fqdn := domain.FQDN(input.Subdomain, input.Zone)
-	logger.Debugf("Creating Access Application", map[string]any{"domain": fqdn})
+	appDomain := applicationDomain(input.Zone, input.Subdomain, input.Path)
+	logger.Debugf("Creating Access Application", map[string]any{"domain": appDomain})
 
-	appID, err := h.accessService.CreateApplication(ctx, AccessApplicationParams{
-		Name:      fmt.Sprintf("moley-%s", fqdn),
-		Domain:    fqdn,
-		Access:    input.Access,
-		PolicyIDs: input.PolicyIDs,
-	})
+	appID, err := h.accessService.CreateApplication(ctx, applicationParams(input))
 	if err != nil {
-		return AppOutput{}, fmt.Errorf("failed to create Access Application for %s: %w", fqdn, err)
+		return AppOutput{}, fmt.Errorf("failed to create Access Application for %s: %w", appDomain, err)
 	}
 
-	logger.Infof("Access Application created", map[string]any{"domain": fqdn, "app_id": appID})
+	logger.Infof("Access Application created", map[string]any{"domain": appDomain, "app_id": appID})
 	return AppOutput{
 		Zone:      input.Zone,
 		Subdomain: input.Subdomain,
+		Path:      input.Path,
 		AppID:     appID,
 	}, nil
 }
+
+// Update applies access and policy changes to the existing application.
+// The domain is part of the key, so every field that can change here is mutable.
+func (h *appHandler) Update(ctx context.Context, current framework.Snapshot[AppInput, AppOutput], input AppInput) (AppOutput, error) {
+	if current.Output.AppID == "" {
+		return AppOutput{}, framework.ErrReplaceRequired
+	}
+
+	appDomain := applicationDomain(input.Zone, input.Subdomain, input.Path)
+	logger.Debugf("Updating Access Application", map[string]any{"domain": appDomain, "app_id": current.Output.AppID})
+
+	if err := h.accessService.UpdateApplication(ctx, current.Output.AppID, applicationParams(input)); err != nil {
+		return AppOutput{}, fmt.Errorf("failed to update Access Application for %s: %w", appDomain, err)
+	}
+
+	logger.Infof("Access Application updated", map[string]any{"domain": appDomain, "app_id": current.Output.AppID})
+	return AppOutput{
+		Zone:      input.Zone,
+		Subdomain: input.Subdomain,
+		Path:      input.Path,
+		AppID:     current.Output.AppID,
+	}, nil
+}
+
+// applicationName names the application after its hostname and tunnel. The name
+// is the ownership marker Recover looks for, as Access has no free-form comment.
+func applicationName(input AppInput) string {
+	return fmt.Sprintf("moley-%s (tunnel %s)", applicationDomain(input.Zone, input.Subdomain, input.Path), input.TunnelName)
+}
+
+func applicationParams(input AppInput) AccessApplicationParams {
+	appDomain := applicationDomain(input.Zone, input.Subdomain, input.Path)
+	return AccessApplicationParams{
+		Name:      applicationName(input),
+		Domain:    appDomain,
+		Access:    input.Access,
+		PolicyIDs: input.PolicyIDs,
+	}
+}
+
 func (h *appHandler) Destroy(ctx context.Context, output AppOutput) error {
-	fqdn := domain.FQDN(output.Subdomain, output.Zone)
-	logger.Debugf("Deleting Access Application", map[string]any{"domain": fqdn, "app_id": output.AppID})
+	appDomain := applicationDomain(output.Zone, output.Subdomain, output.Path)
+	logger.Debugf("Deleting Access Application", map[string]any{"domain": appDomain, "app_id": output.AppID})
 
 	if err := h.accessService.DeleteApplication(ctx, output.AppID); err != nil {
-		return fmt.Errorf("failed to delete Access Application for %s: %w", fqdn, err)
+		return fmt.Errorf("failed to delete Access Application for %s: %w", appDomain, err)
 	}
 
-	logger.Infof("Access Application deleted", map[string]any{"domain": fqdn})
+	logger.Infof("Access Application deleted", map[string]any{"domain": appDomain})
 	return nil
 }
 
+// Observe reads the application back and keeps the fields moley.yml configures,
+// so settings Cloudflare fills in with defaults are not reported as drift.
+func (h *appHandler) Observe(ctx context.Context, current framework.Snapshot[AppInput, AppOutput]) (AppInput, error) {
+	app, found, err := h.accessService.GetApplication(ctx, current.Output.AppID)
+	if err != nil {
+		return AppInput{}, fmt.Errorf("failed to read Access Application: %w", err)
+	}
+	if !found {
+		return AppInput{}, framework.ErrResourceMissing
+	}
+
+	observed := current.Input
+	observed.PolicyIDs = app.PolicyIDs
+	observed.Access = domain.AccessConfig{
+		Providers: app.Providers,
+		Raw:       make(map[string]any, len(current.Input.Access.Raw)),
+	}
+	for field := range current.Input.Access.Raw {
+		observed.Access.Raw[field] = app.Fields[field]
+	}
+	return observed, nil
+}
+
 func (h *appHandler) Check(ctx context.Context, output AppOutput) (framework.Status, error) {
-	_, exists, err := h.accessService.FindApplication(ctx, domain.FQDN(output.Subdomain, output.Zone))
+	_, exists, err := h.accessService.FindApplication(ctx, applicationDomain(output.Zone, output.Subdomain, output.Path))
 	if err != nil {
 		return framework.StatusUnknown, fmt.Errorf("failed to check Access Application: %w", err)
 	}
@@ -100,17 +188,45 @@ func (h *appHandler) Check(ctx context.Context, output AppOutput) (framework.Sta
 	return framework.StatusDown, nil
 }
 
+// Describe summarizes the application by its ID and attached policies.
+func (h *appHandler) Describe(snap framework.Snapshot[AppInput, AppOutput]) string {
+	if len(snap.Input.PolicyIDs) == 0 {
+		return fmt.Sprintf("app %s", snap.Output.AppID)
+	}
+	return fmt.Sprintf("app %s, policies %s", snap.Output.AppID, strings.Join(snap.Input.PolicyIDs, ", "))
+}
+
+// Recover finds the application protecting input's hostname. One not named by
+// applicationName, including one named by a release before the tunnel was part
+// of the name, is reported with framework.ErrNotOwned. Recover never changes the
+// application: an adopted one gets its name on its next Update.
 func (h *appHandler) Recover(ctx context.Context, input AppInput) (AppOutput, framework.Status, error) {
-	appID, exists, err := h.accessService.FindApplication(ctx, domain.FQDN(input.Subdomain, input.Zone))
+	appDomain := applicationDomain(input.Zone, input.Subdomain, input.Path)
+	appID, exists, err := h.accessService.FindApplication(ctx, appDomain)
 	if err != nil {
 		return AppOutput{}, framework.StatusUnknown, err
 	}
 	if !exists {
 		return AppOutput{}, framework.StatusDown, nil
 	}
-	return AppOutput{
+	output := AppOutput{
 		Zone:      input.Zone,
 		Subdomain: input.Subdomain,
+		Path:      input.Path,
 		AppID:     appID,
-	}, framework.StatusUp, nil
+	}
+
+	owned, err := h.accessService.ApplicationNamed(ctx, appID, applicationName(input))
+	if err != nil {
+		return AppOutput{}, framework.StatusUnknown, fmt.Errorf("failed to check Access Application ownership: %w", err)
+	}
+	if !owned {
+		return output, framework.StatusUp, fmt.Errorf("%s is protected by Access Application %s, which moley did not create for tunnel %s: %w", appDomain, appID, input.TunnelName, framework.ErrNotOwned)
+	}
+	return output, framework.StatusUp, nil
+}
+
+// ID returns the ID of the Access application.
+func (h *appHandler) ID(_ context.Context, output AppOutput) (string, error) {
+	return output.AppID, nil
 }
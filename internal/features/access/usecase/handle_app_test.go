@@ -0,0 +1,129 @@
+package access_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stupside/moley/v2/internal/domain"
+	access "github.com/stupside/moley/v2/internal/features/access/usecase"
+	framework "github.com/stupside/moley/v2/internal/platform/orchestration"
+)
+
+type fakeApp struct {
+	Name   string
+	Domain string
+}
+
+// fakeAccess keeps Access applications in memory and counts the changes made to them.
+type fakeAccess struct {
+	apps    map[string]*fakeApp
+	updates int
+}
+
+func (f *fakeAccess) CreateApplication(_ context.Context, params access.AccessApplicationParams) (string, error) {
+	id := fmt.Sprintf("app-%d", len(f.apps)+1)
+	f.apps[id] = &fakeApp{Name: params.Name, Domain: params.Domain}
+	return id, nil
+}
+
+func (f *fakeAccess) UpdateApplication(_ context.Context, appID string, params access.AccessApplicationParams) error {
+	f.updates++
+	f.apps[appID].Name = params.Name
+	return nil
+}
+
+func (f *fakeAccess) DeleteApplication(_ context.Context, appID string) error {
+	delete(f.apps, appID)
+	return nil
+}
+
+func (f *fakeAccess) GetApplication(_ context.Context, appID string) (access.ObservedApplication, bool, error) {
+	_, ok := f.apps[appID]
+	return access.ObservedApplication{}, ok, nil
+}
+
+func (f *fakeAccess) FindApplication(_ context.Context, appDomain string) (string, bool, error) {
+	for id, app := range f.apps {
+		if app.Domain == appDomain {
+			return id, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+func (f *fakeAccess) ApplicationNamed(_ context.Context, appID string, name string) (bool, error) {
+	app, ok := f.apps[appID]
+	return ok && app.Name == name, nil
+}
+
+func TestAppRecover(t *testing.T) {
+	input := access.AppInput{Zone: "example.com", Subdomain: "api", TunnelName: "demo"}
+
+	tests := []struct {
+		name       string
+		existing   string // name of the application protecting the hostname, if any
+		wantStatus framework.Status
+		wantErr    error
+	}{
+		{name: "missing", wantStatus: framework.StatusDown},
+		{name: "owned", existing: "moley-api.example.com (tunnel demo)", wantStatus: framework.StatusUp},
+		{name: "other tunnel", existing: "moley-api.example.com (tunnel other)", wantStatus: framework.StatusUp, wantErr: framework.ErrNotOwned},
+		{name: "earlier release", existing: "moley-api.example.com", wantStatus: framework.StatusUp, wantErr: framework.ErrNotOwned},
+		{name: "not moley", existing: "API", wantStatus: framework.StatusUp, wantErr: framework.ErrNotOwned},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeAccess{apps: map[string]*fakeApp{}}
+			if tt.existing != "" {
+				api.apps["app-1"] = &fakeApp{Name: tt.existing, Domain: "api.example.com"}
+			}
+
+			output, status, err := access.NewHandler(api).Recover(context.Background(), input)
+			if status != tt.wantStatus {
+				t.Errorf("expected %s, got %s (%v)", tt.wantStatus, status, err)
+			}
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+			if tt.existing != "" && output.AppID != "app-1" {
+				t.Errorf("expected the application to be identified, got %q", output.AppID)
+			}
+			if api.updates != 0 {
+				t.Errorf("Recover must not change the application, got %d updates", api.updates)
+			}
+			if tt.existing != "" && api.apps["app-1"].Name != tt.existing {
+				t.Errorf("expected the name to be kept, got %q", api.apps["app-1"].Name)
+			}
+		})
+	}
+}
+
+func TestAppUpdateRenamesAdoptedApplication(t *testing.T) {
+	api := &fakeAccess{apps: map[string]*fakeApp{
+		"app-1": {Name: "moley-api.example.com", Domain: "api.example.com"},
+	}}
+	h := access.NewHandler(api)
+	input := access.AppInput{Zone: "example.com", Subdomain: "api", TunnelName: "demo", Access: domain.AccessConfig{Providers: []string{"github"}}}
+
+	output, _, err := h.Recover(context.Background(), input)
+	if !errors.Is(err, framework.ErrNotOwned) {
+		t.Fatalf("expected ErrNotOwned, got %v", err)
+	}
+
+	current := framework.Snapshot[access.AppInput, access.AppOutput]{Input: input, Output: output}
+	if _, err := h.Update(context.Background(), current, input); err != nil {
+		t.Fatal(err)
+	}
+	if got := api.apps["app-1"].Name; got != "moley-api.example.com (tunnel demo)" {
+		t.Errorf("expected Update to give the application its marker name, got %q", got)
+	}
+	if _, status, err := h.Recover(context.Background(), input); status != framework.StatusUp || err != nil {
+		t.Errorf("expected the renamed application to be owned, got %s, %v", status, err)
+	}
+}
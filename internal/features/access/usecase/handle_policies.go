@@ -13,12 +13,17 @@ const PolicyHandlerName = "access-policies"
 
 type PolicyManager interface {
 	CreatePolicy(ctx context.Context, policy domain.Policy) (string, error)
+	UpdatePolicy(ctx context.Context, policyID string, policy domain.Policy) error
 	DeletePolicy(ctx context.Context, policyID string) error
+	GetPolicy(ctx context.Context, policyID string) (map[string]any, bool, error)
 	FindPolicy(ctx context.Context, name string) (string, bool, error)
 }
 
 type PolicyInput struct {
 	Policy domain.Policy `json:"policy"`
+	// TunnelName only marks ownership in the policy name, so it is kept out of
+	// the recorded input and its hash.
+	TunnelName string `json:"-"`
 }
 
 type PolicyOutput struct {
@@ -30,7 +35,13 @@ type policyHandler struct {
 	policyService PolicyManager
 }
 
-var _ framework.Lifecycle[PolicyInput, PolicyOutput] = (*policyHandler)(nil)
+var (
+	_ framework.Lifecycle[PolicyInput, PolicyOutput] = (*policyHandler)(nil)
+	_ framework.Updater[PolicyInput, PolicyOutput]   = (*policyHandler)(nil)
+	_ framework.Observer[PolicyInput, PolicyOutput]  = (*policyHandler)(nil)
+	_ framework.Describer[PolicyInput, PolicyOutput] = (*policyHandler)(nil)
+	_ framework.Identifier[PolicyOutput]             = (*policyHandler)(nil)
+)
 
 func NewPolicyHandler(policyService PolicyManager) *policyHandler {
 	return &policyHandler{policyService: policyService}
@@ -42,8 +53,21 @@ func (h *policyHandler) Key(input PolicyInput) string {
 	return input.Policy.Name
 }
 
+// policyName names the policy after its configured name and tunnel. The name is
+// the ownership marker Recover looks for, as policies have no free-form comment.
+func policyName(input PolicyInput) string {
+	return fmt.Sprintf("moley-%s (tunnel %s)", input.Policy.Name, input.TunnelName)
+}
+
+// ownedPolicy returns the policy body sent to Cloudflare, named by policyName.
+func ownedPolicy(input PolicyInput) domain.Policy {
+	policy := input.Policy
+	policy.Name = policyName(input)
+	return policy
+}
+
 func (h *policyHandler) Create(ctx context.Context, input PolicyInput) (PolicyOutput, error) {
-	id, err := h.policyService.CreatePolicy(ctx, input.Policy)
+	id, err := h.policyService.CreatePolicy(ctx, ownedPolicy(input))
 	if err != nil {
 		return PolicyOutput{}, fmt.Errorf("failed to create policy %q: %w", input.Policy.Name, err)
 	}
@@ -51,6 +75,38 @@ func (h *policyHandler) Create(ctx context.Context, input PolicyInput) (PolicyOu
 	return PolicyOutput{Name: input.Policy.Name, PolicyID: id}, nil
 }
 
+// Update rewrites the policy rules in place so applications referencing it keep the same ID.
+func (h *policyHandler) Update(ctx context.Context, current framework.Snapshot[PolicyInput, PolicyOutput], input PolicyInput) (PolicyOutput, error) {
+	if current.Output.PolicyID == "" {
+		return PolicyOutput{}, framework.ErrReplaceRequired
+	}
+	if err := h.policyService.UpdatePolicy(ctx, current.Output.PolicyID, ownedPolicy(input)); err != nil {
+		return PolicyOutput{}, fmt.Errorf("failed to update policy %q: %w", input.Policy.Name, err)
+	}
+	logger.Infof("Access policy updated", map[string]any{"name": input.Policy.Name, "id": current.Output.PolicyID})
+	return PolicyOutput{Name: input.Policy.Name, PolicyID: current.Output.PolicyID}, nil
+}
+
+// Observe reads the policy back, limited to the fields moley.yml configures.
+func (h *policyHandler) Observe(ctx context.Context, current framework.Snapshot[PolicyInput, PolicyOutput]) (PolicyInput, error) {
+	live, found, err := h.policyService.GetPolicy(ctx, current.Output.PolicyID)
+	if err != nil {
+		return PolicyInput{}, fmt.Errorf("failed to read policy %q: %w", current.Output.Name, err)
+	}
+	if !found {
+		return PolicyInput{}, framework.ErrResourceMissing
+	}
+
+	observed := domain.Policy{
+		Name:  current.Input.Policy.Name,
+		Extra: make(map[string]any, len(current.Input.Policy.Extra)),
+	}
+	for field := range current.Input.Policy.Extra {
+		observed.Extra[field] = live[field]
+	}
+	return PolicyInput{Policy: observed}, nil
+}
+
 func (h *policyHandler) Destroy(ctx context.Context, output PolicyOutput) error {
 	if err := h.policyService.DeletePolicy(ctx, output.PolicyID); err != nil {
 		return fmt.Errorf("failed to delete policy %q: %w", output.Name, err)
@@ -59,24 +115,49 @@ func (h *policyHandler) Destroy(ctx context.Context, output PolicyOutput) error
 	return nil
 }
 
+// Check looks the policy up by ID, so one created under its plain name by an
+// earlier release is still found.
 func (h *policyHandler) Check(ctx context.Context, output PolicyOutput) (framework.Status, error) {
-	id, found, err := h.policyService.FindPolicy(ctx, output.Name)
+	_, found, err := h.policyService.GetPolicy(ctx, output.PolicyID)
 	if err != nil {
 		return framework.StatusUnknown, err
 	}
-	if !found || id != output.PolicyID {
+	if !found {
 		return framework.StatusDown, nil
 	}
 	return framework.StatusUp, nil
 }
 
+// Describe summarizes the policy by its ID.
+func (h *policyHandler) Describe(snap framework.Snapshot[PolicyInput, PolicyOutput]) string {
+	return fmt.Sprintf("policy %s", snap.Output.PolicyID)
+}
+
+// Recover finds the policy named by policyName. A policy with the configured
+// name but without the marker, including one created by a release before the
+// marker, is reported with framework.ErrNotOwned. An adopted policy gets its
+// name on its next Update.
 func (h *policyHandler) Recover(ctx context.Context, input PolicyInput) (PolicyOutput, framework.Status, error) {
-	id, found, err := h.policyService.FindPolicy(ctx, input.Policy.Name)
+	id, found, err := h.policyService.FindPolicy(ctx, policyName(input))
+	if err != nil {
+		return PolicyOutput{}, framework.StatusUnknown, err
+	}
+	if found {
+		return PolicyOutput{Name: input.Policy.Name, PolicyID: id}, framework.StatusUp, nil
+	}
+
+	id, found, err = h.policyService.FindPolicy(ctx, input.Policy.Name)
 	if err != nil {
 		return PolicyOutput{}, framework.StatusUnknown, err
 	}
 	if !found {
 		return PolicyOutput{}, framework.StatusDown, nil
 	}
-	return PolicyOutput{Name: input.Policy.Name, PolicyID: id}, framework.StatusUp, nil
+	output := PolicyOutput{Name: input.Policy.Name, PolicyID: id}
+	return output, framework.StatusUp, fmt.Errorf("access policy %q (%s) was not created by moley for tunnel %s: %w", input.Policy.Name, id, input.TunnelName, framework.ErrNotOwned)
+}
+
+// ID returns the ID of the reusable Access policy.
+func (h *policyHandler) ID(_ context.Context, output PolicyOutput) (string, error) {
+	return output.PolicyID, nil
 }
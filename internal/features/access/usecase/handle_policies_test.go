@@ -0,0 +1,97 @@
+package access_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stupside/moley/v2/internal/domain"
+	access "github.com/stupside/moley/v2/internal/features/access/usecase"
+	framework "github.com/stupside/moley/v2/internal/platform/orchestration"
+)
+
+// fakePolicies keeps reusable Access policies in memory, by ID.
+type fakePolicies struct {
+	names   map[string]string
+	updates int
+}
+
+func (f *fakePolicies) CreatePolicy(_ context.Context, policy domain.Policy) (string, error) {
+	id := fmt.Sprintf("policy-%d", len(f.names)+1)
+	f.names[id] = policy.Name
+	return id, nil
+}
+
+func (f *fakePolicies) UpdatePolicy(_ context.Context, policyID string, policy domain.Policy) error {
+	f.updates++
+	f.names[policyID] = policy.Name
+	return nil
+}
+
+func (f *fakePolicies) DeletePolicy(_ context.Context, policyID string) error {
+	delete(f.names, policyID)
+	return nil
+}
+
+func (f *fakePolicies) GetPolicy(_ context.Context, policyID string) (map[string]any, bool, error) {
+	_, ok := f.names[policyID]
+	return map[string]any{}, ok, nil
+}
+
+func (f *fakePolicies) FindPolicy(_ context.Context, name string) (string, bool, error) {
+	for id, n := range f.names {
+		if n == name {
+			return id, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+func TestPolicyRecover(t *testing.T) {
+	input := access.PolicyInput{Policy: domain.Policy{Name: "admins"}, TunnelName: "demo"}
+
+	tests := []struct {
+		name       string
+		existing   string // name of the policy on Cloudflare, if any
+		wantStatus framework.Status
+		wantErr    error
+	}{
+		{name: "missing", wantStatus: framework.StatusDown},
+		{name: "owned", existing: "moley-admins (tunnel demo)", wantStatus: framework.StatusUp},
+		{name: "plain name", existing: "admins", wantStatus: framework.StatusUp, wantErr: framework.ErrNotOwned},
+		{name: "other tunnel", existing: "moley-admins (tunnel other)", wantStatus: framework.StatusDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakePolicies{names: map[string]string{}}
+			if tt.existing != "" {
+				api.names["policy-1"] = tt.existing
+			}
+
+			output, status, err := access.NewPolicyHandler(api).Recover(context.Background(), input)
+			if status != tt.wantStatus {
+				t.Errorf("expected %s, got %s (%v)", tt.wantStatus, status, err)
+			}
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected %v, got %v", tt.wantErr, err)
+				}
+				if msg := err.Error(); strings.ToLower(msg[:1]) != msg[:1] {
+					t.Errorf("expected a lowercase error string, got %q", msg)
+				}
+			}
+			if status == framework.StatusUp && output.PolicyID != "policy-1" {
+				t.Errorf("expected the policy to be identified, got %q", output.PolicyID)
+			}
+			if api.updates != 0 {
+				t.Errorf("Recover must not change the policy, got %d updates", api.updates)
+			}
+		})
+	}
+}
@@ -4,6 +4,8 @@ package cloudflare
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 
 	"github.com/stupside/moley/v2/internal/domain"
 	logger "github.com/stupside/moley/v2/internal/platform/logging"
@@ -17,20 +19,43 @@ import (
 type DNSService struct {
 	client *cfgo.Client
 	dryRun bool
+
+	// zoneIDs caches zone IDs by name; a tunnel serving several zones looks
+	// each one up for every record otherwise.
+	mu      sync.Mutex
+	zoneIDs map[string]string
 }
 
 func NewDNSService(client *cfgo.Client, dryRun bool) *DNSService {
 	return &DNSService{
-		dryRun: dryRun,
-		client: client,
+		dryRun:  dryRun,
+		client:  client,
+		zoneIDs: make(map[string]string),
 	}
 }
 
+const tunnelTargetSuffix = ".cfargotunnel.com"
+
 func cnameTarget(tunnelUUID string) string {
-	return tunnelUUID + ".cfargotunnel.com"
+	return tunnelUUID + tunnelTargetSuffix
+}
+
+// ownerComment is the comment marking a record as created by moley for a tunnel.
+// Records without it are not adopted unless the user asks to (see RecordOwned).
+func ownerComment(tunnelName string) string {
+	return fmt.Sprintf("managed by moley (tunnel %s)", tunnelName)
+}
+
+// namedRecord is the subset of a DNS record needed to inspect where it points
+// and who owns it.
+type namedRecord struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Content string `json:"content"`
+	Comment string `json:"comment"`
 }
 
-func (c *DNSService) RouteRecord(ctx context.Context, tunnelUUID string, zoneName string, subdomain string) error {
+func (c *DNSService) RouteRecord(ctx context.Context, tunnelUUID string, tunnelName string, zoneName string, subdomain string) error {
 	if c.dryRun {
 		logger.Debug("Dry run: skipping DNS record creation")
 		return nil
@@ -65,6 +90,7 @@ func (c *DNSService) RouteRecord(ctx context.Context, tunnelUUID string, zoneNam
 			Name:    cfgo.F(name),
 			Proxied: cfgo.F(true),
 			TTL:     cfgo.F(dns.TTL1), // automatic
+			Comment: cfgo.F(ownerComment(tunnelName)),
 		},
 	},
 		option.WithJSONSet("type", "CNAME"),
@@ -77,6 +103,25 @@ func (c *DNSService) RouteRecord(ctx context.Context, tunnelUUID string, zoneNam
 }
 
 func (c *DNSService) getZoneID(ctx context.Context, zoneName string) (string, error) {
+	c.mu.Lock()
+	zoneID, ok := c.zoneIDs[zoneName]
+	c.mu.Unlock()
+	if ok {
+		return zoneID, nil
+	}
+
+	zoneID, err := c.lookupZoneID(ctx, zoneName)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	c.zoneIDs[zoneName] = zoneID
+	c.mu.Unlock()
+	return zoneID, nil
+}
+
+func (c *DNSService) lookupZoneID(ctx context.Context, zoneName string) (string, error) {
 	pager := c.client.Zones.ListAutoPaging(ctx, zones.ZoneListParams{
 		Name: cfgo.F(zoneName),
 	})
@@ -154,6 +199,81 @@ func (c *DNSService) DeleteRecord(ctx context.Context, tunnelUUID string, zoneNa
 	return nil
 }
 
+// findRecordByName returns the record with the given name, whatever it points to.
+func (c *DNSService) findRecordByName(ctx context.Context, zoneID, name string) (namedRecord, bool, error) {
+	var env struct {
+		Result []namedRecord `json:"result"`
+	}
+	path := fmt.Sprintf("zones/%s/dns_records", zoneID)
+	if err := c.client.Get(ctx, path, nil, &env, option.WithQuery("name", name)); err != nil {
+		return namedRecord{}, false, fmt.Errorf("failed to list DNS records named %s: %w", name, err)
+	}
+	if len(env.Result) == 0 {
+		return namedRecord{}, false, nil
+	}
+	return env.Result[0], true, nil
+}
+
+// RecordTunnel returns the tunnel UUID the record currently points to. When the
+// record no longer targets a tunnel, its raw content is returned instead.
+func (c *DNSService) RecordTunnel(ctx context.Context, zoneName string, subdomain string) (string, bool, error) {
+	zoneID, err := c.getZoneID(ctx, zoneName)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get zone ID for zone %s: %w", zoneName, err)
+	}
+
+	record, found, err := c.findRecordByName(ctx, zoneID, domain.FQDN(subdomain, zoneName))
+	if err != nil || !found {
+		return "", found, err
+	}
+
+	if record.Type == "CNAME" && strings.HasSuffix(record.Content, tunnelTargetSuffix) {
+		return strings.TrimSuffix(record.Content, tunnelTargetSuffix), true, nil
+	}
+	return record.Content, true, nil
+}
+
+// RepointRecord points the record with the given name at the tunnel, keeping its
+// ID. The record is created when it does not exist.
+func (c *DNSService) RepointRecord(ctx context.Context, tunnelUUID string, tunnelName string, zoneName string, subdomain string) error {
+	if c.dryRun {
+		logger.Debug("Dry run: skipping DNS record update")
+		return nil
+	}
+
+	zoneID, err := c.getZoneID(ctx, zoneName)
+	if err != nil {
+		return fmt.Errorf("failed to get zone ID for zone %s: %w", zoneName, err)
+	}
+
+	name := domain.FQDN(subdomain, zoneName)
+
+	record, found, err := c.findRecordByName(ctx, zoneID, name)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return c.RouteRecord(ctx, tunnelUUID, tunnelName, zoneName, subdomain)
+	}
+
+	_, err = c.client.DNS.Records.Edit(ctx, record.ID, dns.RecordEditParams{
+		ZoneID: cfgo.F(zoneID),
+		Record: dns.RecordParam{
+			Name:    cfgo.F(name),
+			Proxied: cfgo.F(true),
+			TTL:     cfgo.F(dns.TTL1), // automatic
+			Comment: cfgo.F(ownerComment(tunnelName)),
+		},
+	},
+		option.WithJSONSet("type", "CNAME"),
+		option.WithJSONSet("content", cnameTarget(tunnelUUID)),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update DNS record for subdomain %s: %w", subdomain, err)
+	}
+	return nil
+}
+
 func (c *DNSService) RecordExists(ctx context.Context, tunnelUUID string, zoneName string, subdomain string) (bool, error) {
 	if c.dryRun {
 		return true, nil
@@ -179,3 +299,40 @@ func (c *DNSService) RecordExists(ctx context.Context, tunnelUUID string, zoneNa
 	}
 	return false, nil
 }
+
+// RecordID returns the ID of the record with the given name.
+func (c *DNSService) RecordID(ctx context.Context, zoneName string, subdomain string) (string, bool, error) {
+	if c.dryRun {
+		return "dry-run-record", true, nil
+	}
+
+	zoneID, err := c.getZoneID(ctx, zoneName)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get zone ID: %w", err)
+	}
+
+	record, found, err := c.findRecordByName(ctx, zoneID, domain.FQDN(subdomain, zoneName))
+	if err != nil || !found {
+		return "", found, err
+	}
+	return record.ID, true, nil
+}
+
+// RecordOwned reports whether the record with the given name carries the
+// ownership comment of the tunnel. A missing record is not owned.
+func (c *DNSService) RecordOwned(ctx context.Context, tunnelName string, zoneName string, subdomain string) (bool, error) {
+	if c.dryRun {
+		return true, nil
+	}
+
+	zoneID, err := c.getZoneID(ctx, zoneName)
+	if err != nil {
+		return false, fmt.Errorf("failed to get zone ID: %w", err)
+	}
+
+	record, found, err := c.findRecordByName(ctx, zoneID, domain.FQDN(subdomain, zoneName))
+	if err != nil || !found {
+		return false, err
+	}
+	return record.Comment == ownerComment(tunnelName), nil
+}
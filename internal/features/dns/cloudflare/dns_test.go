@@ -0,0 +1,124 @@
+package cloudflare
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	cfgo "github.com/cloudflare/cloudflare-go/v3"
+	"github.com/cloudflare/cloudflare-go/v3/option"
+)
+
+// zoneAPI serves zone lookups and record creation for the zones it knows, and
+// counts the lookups of each zone.
+type zoneAPI struct {
+	mu      sync.Mutex
+	zones   map[string]string   // zone name to ID
+	lookups map[string]int      // zone name to number of lookups
+	created map[string][]string // zone ID to names of the records created in it
+}
+
+func (f *zoneAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	query := r.URL.Query()
+	// Every list fits on the first page; later pages end auto-paging.
+	lastPage := query.Get("page") != "" && query.Get("page") != "1"
+
+	path := strings.Trim(r.URL.Path, "/")
+	zoneID, records := strings.CutSuffix(strings.TrimPrefix(path, "zones/"), "/dns_records")
+
+	switch {
+	case r.Method == http.MethodGet && path == "zones":
+		result := []any{}
+		if name := query.Get("name"); !lastPage {
+			f.lookups[name]++
+			if id, ok := f.zones[name]; ok {
+				result = append(result, map[string]string{"id": id, "name": name})
+			}
+		}
+		respond(w, result)
+
+	case r.Method == http.MethodGet && records:
+		respond(w, []any{})
+
+	case r.Method == http.MethodPost && records:
+		var rec struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&rec); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.created[zoneID] = append(f.created[zoneID], rec.Name)
+		respond(w, map[string]string{"id": "record", "name": rec.Name})
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func respond(w http.ResponseWriter, result any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"success":  true,
+		"errors":   []any{},
+		"messages": []any{},
+		"result":   result,
+	})
+}
+
+func TestDNSServiceCachesZoneIDs(t *testing.T) {
+	api := &zoneAPI{
+		zones:   map[string]string{"example.com": "zone-com", "example.org": "zone-org"},
+		lookups: map[string]int{},
+		created: map[string][]string{},
+	}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	svc := NewDNSService(cfgo.NewClient(
+		option.WithBaseURL(srv.URL+"/"),
+		option.WithAPIToken("token"),
+		option.WithMaxRetries(0),
+	), false)
+
+	ctx := context.Background()
+	for _, route := range []struct{ zone, subdomain string }{
+		{"example.com", "*"},
+		{"example.org", "*"},
+		{"example.com", "app"},
+		{"example.org", "api"},
+	} {
+		if err := svc.RouteRecord(ctx, "uuid", "demo", route.zone, route.subdomain); err != nil {
+			t.Fatalf("RouteRecord(%s, %s): %v", route.zone, route.subdomain, err)
+		}
+	}
+
+	for zone, n := range api.lookups {
+		if n != 1 {
+			t.Errorf("expected zone %s to be looked up once, got %d", zone, n)
+		}
+	}
+	if got := api.created["zone-com"]; len(got) != 2 || got[0] != "*.example.com" || got[1] != "app.example.com" {
+		t.Errorf("expected the example.com records in its zone, got %v", got)
+	}
+	if got := api.created["zone-org"]; len(got) != 2 || got[0] != "*.example.org" || got[1] != "api.example.org" {
+		t.Errorf("expected the example.org records in its zone, got %v", got)
+	}
+
+	if err := svc.RouteRecord(ctx, "uuid", "demo", "missing.dev", "app"); err == nil {
+		t.Error("expected an unknown zone to be refused")
+	}
+	if err := svc.RouteRecord(ctx, "uuid", "demo", "missing.dev", "app"); err == nil {
+		t.Error("expected an unknown zone to be refused")
+	}
+	if api.lookups["missing.dev"] != 2 {
+		t.Errorf("expected a failed lookup not to be cached, got %d lookups", api.lookups["missing.dev"])
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/stupside/moley/v2/internal/domain"
 	logger "github.com/stupside/moley/v2/internal/platform/logging"
 	framework "github.com/stupside/moley/v2/internal/platform/orchestration"
 )
@@ -12,9 +13,13 @@ import (
 const HandlerName = "dns-record"
 
 type DNSRouter interface {
-	RouteRecord(ctx context.Context, tunnelUUID string, zoneName string, subdomain string) error
+	RouteRecord(ctx context.Context, tunnelUUID string, tunnelName string, zoneName string, subdomain string) error
 	DeleteRecord(ctx context.Context, tunnelUUID string, zoneName string, subdomain string) error
 	RecordExists(ctx context.Context, tunnelUUID string, zoneName string, subdomain string) (bool, error)
+	RecordTunnel(ctx context.Context, zoneName string, subdomain string) (string, bool, error)
+	RepointRecord(ctx context.Context, tunnelUUID string, tunnelName string, zoneName string, subdomain string) error
+	RecordOwned(ctx context.Context, tunnelName string, zoneName string, subdomain string) (bool, error)
+	RecordID(ctx context.Context, zoneName string, subdomain string) (string, bool, error)
 }
 
 type RecordInput struct {
@@ -37,7 +42,13 @@ type recordHandler struct {
 	dnsService DNSRouter
 }
 
-var _ framework.Lifecycle[RecordInput, RecordOutput] = (*recordHandler)(nil)
+var (
+	_ framework.Lifecycle[RecordInput, RecordOutput] = (*recordHandler)(nil)
+	_ framework.Updater[RecordInput, RecordOutput]   = (*recordHandler)(nil)
+	_ framework.Observer[RecordInput, RecordOutput]  = (*recordHandler)(nil)
+	_ framework.Describer[RecordInput, RecordOutput] = (*recordHandler)(nil)
+	_ framework.Identifier[RecordOutput]             = (*recordHandler)(nil)
+)
 
 func NewHandler(dnsService DNSRouter) *recordHandler {
 	return &recordHandler{dnsService: dnsService}
@@ -57,7 +68,7 @@ func (h *recordHandler) Create(ctx context.Context, input RecordInput) (RecordOu
 		"subdomain": input.Subdomain,
 	})
 
-	if err := h.dnsService.RouteRecord(ctx, input.TunnelUUID, input.Zone, input.Subdomain); err != nil {
+	if err := h.dnsService.RouteRecord(ctx, input.TunnelUUID, input.TunnelName, input.Zone, input.Subdomain); err != nil {
 		return RecordOutput{}, fmt.Errorf("failed to create DNS record for subdomain %s: %w", input.Subdomain, err)
 	}
 
@@ -85,19 +96,89 @@ func (h *recordHandler) Destroy(ctx context.Context, output RecordOutput) error
 	return nil
 }
 
+// Update repoints the record in place when the tunnel changed, so the name never
+// stops resolving. Other changes (e.g. persistence) do not touch the record.
+func (h *recordHandler) Update(ctx context.Context, current framework.Snapshot[RecordInput, RecordOutput], input RecordInput) (RecordOutput, error) {
+	if current.Input.TunnelUUID != input.TunnelUUID {
+		logger.Debugf("Repointing DNS record", map[string]any{
+			"zone":      input.Zone,
+			"subdomain": input.Subdomain,
+		})
+		if err := h.dnsService.RepointRecord(ctx, input.TunnelUUID, input.TunnelName, input.Zone, input.Subdomain); err != nil {
+			return RecordOutput{}, fmt.Errorf("failed to update DNS record for subdomain %s: %w", input.Subdomain, err)
+		}
+		logger.Infof("DNS record updated", map[string]any{"subdomain": input.Subdomain})
+	}
+
+	return RecordOutput{
+		Zone:       input.Zone,
+		Subdomain:  input.Subdomain,
+		TunnelName: input.TunnelName,
+		TunnelUUID: input.TunnelUUID,
+		Persistent: input.Persistent,
+	}, nil
+}
+
+// Observe reports the tunnel the record currently points to.
+func (h *recordHandler) Observe(ctx context.Context, current framework.Snapshot[RecordInput, RecordOutput]) (RecordInput, error) {
+	tunnelUUID, found, err := h.dnsService.RecordTunnel(ctx, current.Input.Zone, current.Input.Subdomain)
+	if err != nil {
+		return RecordInput{}, fmt.Errorf("failed to read DNS record: %w", err)
+	}
+	if !found {
+		return RecordInput{}, framework.ErrResourceMissing
+	}
+
+	observed := current.Input
+	observed.TunnelUUID = tunnelUUID
+	return observed, nil
+}
+
 func (h *recordHandler) Check(ctx context.Context, output RecordOutput) (framework.Status, error) {
 	return h.checkExists(ctx, output.TunnelUUID, output.Zone, output.Subdomain)
 }
 
+// Describe summarizes the record by its hostname and target tunnel.
+func (h *recordHandler) Describe(snap framework.Snapshot[RecordInput, RecordOutput]) string {
+	return fmt.Sprintf("%s -> %s", domain.FQDN(snap.Output.Subdomain, snap.Output.Zone), snap.Output.TunnelUUID)
+}
+
+// Recover finds the record pointing at the tunnel. One without the tunnel's
+// ownership comment is reported with framework.ErrNotOwned.
 func (h *recordHandler) Recover(ctx context.Context, input RecordInput) (RecordOutput, framework.Status, error) {
-	status, err := h.checkExists(ctx, input.TunnelUUID, input.Zone, input.Subdomain)
-	return RecordOutput{
+	output := RecordOutput{
 		Zone:       input.Zone,
 		Subdomain:  input.Subdomain,
 		TunnelName: input.TunnelName,
 		TunnelUUID: input.TunnelUUID,
 		Persistent: input.Persistent,
-	}, status, err
+	}
+
+	status, err := h.checkExists(ctx, input.TunnelUUID, input.Zone, input.Subdomain)
+	if status != framework.StatusUp {
+		return output, status, err
+	}
+
+	owned, err := h.dnsService.RecordOwned(ctx, input.TunnelName, input.Zone, input.Subdomain)
+	if err != nil {
+		return output, framework.StatusUnknown, fmt.Errorf("failed to check DNS record ownership: %w", err)
+	}
+	if !owned {
+		return output, framework.StatusUp, fmt.Errorf("DNS record %s has no moley comment for tunnel %s: %w", domain.FQDN(input.Subdomain, input.Zone), input.TunnelName, framework.ErrNotOwned)
+	}
+	return output, framework.StatusUp, nil
+}
+
+// ID looks up the ID of the DNS record, which the output does not track.
+func (h *recordHandler) ID(ctx context.Context, output RecordOutput) (string, error) {
+	id, found, err := h.dnsService.RecordID(ctx, output.Zone, output.Subdomain)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up DNS record: %w", err)
+	}
+	if !found {
+		return "", framework.ErrResourceMissing
+	}
+	return id, nil
 }
 
 func (h *recordHandler) checkExists(ctx context.Context, tunnelUUID, zone, subdomain string) (framework.Status, error) {
@@ -0,0 +1,229 @@
+package dns_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	cfgo "github.com/cloudflare/cloudflare-go/v3"
+	"github.com/cloudflare/cloudflare-go/v3/option"
+
+	dnscf "github.com/stupside/moley/v2/internal/features/dns/cloudflare"
+	dns "github.com/stupside/moley/v2/internal/features/dns/usecase"
+	framework "github.com/stupside/moley/v2/internal/platform/orchestration"
+)
+
+// --- Fake Cloudflare DNS API ---
+
+type apiRecord struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	Comment string `json:"comment"`
+}
+
+// fakeAPI serves the zone and DNS record endpoints the DNS service uses, for a
+// single zone whose ID is "zone".
+type fakeAPI struct {
+	mu      sync.Mutex
+	records map[string]*apiRecord
+	nextID  int
+}
+
+func (f *fakeAPI) add(record apiRecord) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	record.ID = fmt.Sprintf("record-%d", f.nextID)
+	f.records[record.ID] = &record
+}
+
+func (f *fakeAPI) named(name string) (apiRecord, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, r := range f.records {
+		if r.Name == name {
+			return *r, true
+		}
+	}
+	return apiRecord{}, false
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	query := r.URL.Query()
+	// Every list fits on the first page; later pages end auto-paging.
+	lastPage := query.Get("page") != "" && query.Get("page") != "1"
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/zones":
+		var result []any
+		if !lastPage {
+			result = append(result, map[string]string{"id": "zone", "name": query.Get("name")})
+		}
+		respond(w, result)
+
+	case r.Method == http.MethodGet && r.URL.Path == "/zones/zone/dns_records":
+		result := []any{}
+		for _, rec := range f.records {
+			if lastPage ||
+				(query.Has("name") && rec.Name != query.Get("name")) ||
+				(query.Has("content") && rec.Content != query.Get("content")) {
+				continue
+			}
+			result = append(result, rec)
+		}
+		respond(w, result)
+
+	case r.Method == http.MethodPost && r.URL.Path == "/zones/zone/dns_records":
+		var rec apiRecord
+		if err := json.NewDecoder(r.Body).Decode(&rec); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.nextID++
+		rec.ID = fmt.Sprintf("record-%d", f.nextID)
+		f.records[rec.ID] = &rec
+		respond(w, rec)
+
+	case r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, "/zones/zone/dns_records/"):
+		rec, ok := f.records[strings.TrimPrefix(r.URL.Path, "/zones/zone/dns_records/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(rec); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		respond(w, rec)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func respond(w http.ResponseWriter, result any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"success":  true,
+		"errors":   []any{},
+		"messages": []any{},
+		"result":   result,
+	})
+}
+
+func newHandler(t *testing.T) (*fakeAPI, framework.Lifecycle[dns.RecordInput, dns.RecordOutput]) {
+	t.Helper()
+
+	api := &fakeAPI{records: make(map[string]*apiRecord)}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	client := cfgo.NewClient(
+		option.WithBaseURL(srv.URL+"/"),
+		option.WithAPIToken("token"),
+		option.WithMaxRetries(0),
+	)
+	return api, dns.NewHandler(dnscf.NewDNSService(client, false))
+}
+
+// --- Tests ---
+
+var input = dns.RecordInput{
+	Zone:       "example.com",
+	Subdomain:  "app",
+	TunnelName: "test",
+	TunnelUUID: "uuid",
+}
+
+func TestRecoverOwnership(t *testing.T) {
+	tests := []struct {
+		name    string
+		comment string
+		owned   bool
+	}{
+		{name: "owned", comment: "managed by moley (tunnel test)", owned: true},
+		{name: "foreign", comment: "", owned: false},
+		{name: "another tunnel", comment: "managed by moley (tunnel other)", owned: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, h := newHandler(t)
+			api.add(apiRecord{Type: "CNAME", Name: "app.example.com", Content: "uuid.cfargotunnel.com", Comment: tt.comment})
+
+			_, status, err := h.Recover(context.Background(), input)
+			if status != framework.StatusUp {
+				t.Fatalf("expected the record to be found up, got %s (%v)", status, err)
+			}
+			if tt.owned && err != nil {
+				t.Errorf("expected an owned record, got %v", err)
+			}
+			if !tt.owned && !errors.Is(err, framework.ErrNotOwned) {
+				t.Errorf("expected ErrNotOwned, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRecoverMissingRecord(t *testing.T) {
+	_, h := newHandler(t)
+
+	_, status, err := h.Recover(context.Background(), input)
+	if status != framework.StatusDown || err != nil {
+		t.Errorf("expected a missing record to be down, got %s (%v)", status, err)
+	}
+}
+
+func TestUpdateRepointsAndClaimsRecord(t *testing.T) {
+	ctx := context.Background()
+	api, h := newHandler(t)
+	api.add(apiRecord{Type: "CNAME", Name: "app.example.com", Content: "old.cfargotunnel.com", Comment: "managed by moley (tunnel other)"})
+
+	previous := input
+	previous.TunnelUUID = "old"
+
+	updater := h.(framework.Updater[dns.RecordInput, dns.RecordOutput])
+	if _, err := updater.Update(ctx, framework.Snapshot[dns.RecordInput, dns.RecordOutput]{Input: previous}, input); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	record, _ := api.named("app.example.com")
+	if record.Content != "uuid.cfargotunnel.com" || record.Comment != "managed by moley (tunnel test)" {
+		t.Errorf("expected the record to point at the tunnel with its comment, got %+v", record)
+	}
+	if _, _, err := h.Recover(ctx, input); err != nil {
+		t.Errorf("expected the updated record to be owned, got %v", err)
+	}
+}
+
+func TestObserveReportsTarget(t *testing.T) {
+	ctx := context.Background()
+	api, h := newHandler(t)
+	api.add(apiRecord{Type: "CNAME", Name: "app.example.com", Content: "other.cfargotunnel.com"})
+
+	observer := h.(framework.Observer[dns.RecordInput, dns.RecordOutput])
+	observed, err := observer.Observe(ctx, framework.Snapshot[dns.RecordInput, dns.RecordOutput]{Input: input})
+	if err != nil {
+		t.Fatalf("Observe: %v", err)
+	}
+	if observed.TunnelUUID != "other" {
+		t.Errorf("expected the record to point at tunnel other, got %q", observed.TunnelUUID)
+	}
+
+	missing := input
+	missing.Subdomain = "gone"
+	if _, err := observer.Observe(ctx, framework.Snapshot[dns.RecordInput, dns.RecordOutput]{Input: missing}); !errors.Is(err, framework.ErrResourceMissing) {
+		t.Errorf("expected ErrResourceMissing for a missing record, got %v", err)
+	}
+}
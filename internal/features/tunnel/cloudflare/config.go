@@ -1,17 +1,111 @@
 // Package cloudflare provides Cloudflare-specific implementations.
 package cloudflare
 
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/stupside/moley/v2/internal/domain"
+)
+
 // runConfig is the YAML config format for `cloudflared tunnel run`.
 type runConfig struct {
-	Tunnel          string        `yaml:"tunnel" validate:"required"`
-	Logfile         string        `yaml:"logfile,omitempty"`
-	Loglevel        string        `yaml:"loglevel,omitempty"`
-	CredentialsFile string        `yaml:"credentials_file" validate:"required"`
-	Ingress         []ingressRule `yaml:"ingress" validate:"required"`
+	Tunnel          string         `yaml:"tunnel" validate:"required"`
+	Loglevel        string         `yaml:"loglevel,omitempty"`
+	CredentialsFile string         `yaml:"credentials_file" validate:"required"`
+	OriginRequest   *originRequest `yaml:"originRequest,omitempty"`
+	Ingress         []ingressRule  `yaml:"ingress" validate:"required"`
 }
 
-// ingressRule maps a hostname to a local service in the cloudflared config.
+// ingressRule maps a hostname, and optionally a path regex, to a local service
+// in the cloudflared config.
 type ingressRule struct {
-	Service  string `yaml:"service" validate:"required"`
-	Hostname string `yaml:"hostname,omitempty"`
+	Service       string         `yaml:"service" validate:"required"`
+	Hostname      string         `yaml:"hostname,omitempty"`
+	Path          string         `yaml:"path,omitempty"`
+	OriginRequest *originRequest `yaml:"originRequest,omitempty"`
+}
+
+// originRequest is the cloudflared originRequest block. Set at the top level it
+// applies to every rule; set on a rule it overrides those defaults field by field.
+type originRequest struct {
+	NoTLSVerify            *bool  `yaml:"noTLSVerify,omitempty"`
+	OriginServerName       string `yaml:"originServerName,omitempty"`
+	CAPool                 string `yaml:"caPool,omitempty"`
+	HTTPHostHeader         string `yaml:"httpHostHeader,omitempty"`
+	ConnectTimeout         string `yaml:"connectTimeout,omitempty"`
+	KeepAliveTimeout       string `yaml:"keepAliveTimeout,omitempty"`
+	HTTP2Origin            *bool  `yaml:"http2Origin,omitempty"`
+	DisableChunkedEncoding *bool  `yaml:"disableChunkedEncoding,omitempty"`
+}
+
+// newOriginRequest converts origin to the cloudflared format, or returns nil when
+// it is unset.
+func newOriginRequest(origin *domain.OriginConfig) *originRequest {
+	if origin == nil {
+		return nil
+	}
+	return &originRequest{
+		NoTLSVerify:            origin.NoTLSVerify,
+		OriginServerName:       origin.OriginServerName,
+		CAPool:                 origin.CAPool,
+		HTTPHostHeader:         origin.HTTPHostHeader,
+		ConnectTimeout:         formatDuration(origin.ConnectTimeout),
+		KeepAliveTimeout:       formatDuration(origin.KeepAliveTimeout),
+		HTTP2Origin:            origin.HTTP2Origin,
+		DisableChunkedEncoding: origin.DisableChunkedEncoding,
+	}
+}
+
+// formatDuration renders d as cloudflared parses it, leaving zero unset.
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+// ingressRules builds one rule per app. cloudflared uses the first rule that
+// matches, so the rule without a path comes after the path rules of its
+// hostname. Regexes have no order of specificity, so path rules keep the order
+// they are configured in, as do hostnames, by their first app.
+func ingressRules(ingress *domain.Ingress) ([]ingressRule, error) {
+	rules := make([]ingressRule, 0, len(ingress.Apps))
+	hostOrder := make(map[string]int, len(ingress.Apps))
+	for _, app := range ingress.Apps {
+		if app.Expose.Path != "" {
+			if _, err := regexp.Compile(app.Expose.Path); err != nil {
+				return nil, fmt.Errorf("invalid path regex %q for subdomain %s: %w", app.Expose.Path, app.Expose.Subdomain, err)
+			}
+		}
+		hostname := ingress.Hostname(app)
+		if _, ok := hostOrder[hostname]; !ok {
+			hostOrder[hostname] = len(hostOrder)
+		}
+		rules = append(rules, ingressRule{
+			Service:       app.Target.GetTargetURL(),
+			Hostname:      hostname,
+			Path:          app.Expose.Path,
+			OriginRequest: newOriginRequest(app.Origin),
+		})
+	}
+
+	slices.SortStableFunc(rules, func(a, b ingressRule) int {
+		return cmp.Or(
+			cmp.Compare(hostOrder[a.Hostname], hostOrder[b.Hostname]),
+			cmp.Compare(pathRank(a), pathRank(b)),
+		)
+	})
+	return rules, nil
+}
+
+// pathRank sorts the rule without a path after the path rules of its hostname.
+func pathRank(rule ingressRule) int {
+	if rule.Path == "" {
+		return 1
+	}
+	return 0
 }
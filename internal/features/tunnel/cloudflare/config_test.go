@@ -0,0 +1,66 @@
+package cloudflare
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stupside/moley/v2/internal/domain"
+)
+
+func TestIngressRulesOrder(t *testing.T) {
+	app := func(subdomain, path string, port int) domain.AppConfig {
+		return domain.AppConfig{
+			Target: domain.TargetConfig{Protocol: domain.ProtocolHTTP, Hostname: "localhost", Port: port},
+			Expose: domain.ExposeConfig{Subdomain: subdomain, Path: path},
+		}
+	}
+	ingress := &domain.Ingress{
+		Zone: "example.com",
+		Mode: domain.IngressModeSubdomain,
+		Apps: []domain.AppConfig{
+			app("app", "", 3000),
+			app("web", "", 4000),
+			app("app", "^/api/v1/users", 8081),
+			app("app", "^/api/(v1|v2)/.*", 8080),
+			app("web", "^/static/", 4001),
+		},
+	}
+
+	rules, err := ingressRules(ingress)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type route struct{ hostname, path string }
+	got := make([]route, len(rules))
+	for i, rule := range rules {
+		got[i] = route{rule.Hostname, rule.Path}
+	}
+	// The shorter regex matches more, so it must stay behind the path listed first.
+	want := []route{
+		{"app.example.com", "^/api/v1/users"},
+		{"app.example.com", "^/api/(v1|v2)/.*"},
+		{"app.example.com", ""},
+		{"web.example.com", "^/static/"},
+		{"web.example.com", ""},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("unexpected rule order\n got: %v\nwant: %v", got, want)
+	}
+	if rules[0].Service != "http://localhost:8081" {
+		t.Errorf("expected each rule to keep its app's service, got %s", rules[0].Service)
+	}
+}
+
+func TestIngressRulesRejectInvalidPath(t *testing.T) {
+	ingress := &domain.Ingress{
+		Zone: "example.com",
+		Apps: []domain.AppConfig{{
+			Target: domain.TargetConfig{Protocol: domain.ProtocolHelloWorld},
+			Expose: domain.ExposeConfig{Subdomain: "app", Path: "^/(api"},
+		}},
+	}
+	if _, err := ingressRules(ingress); err == nil {
+		t.Error("expected an invalid path regex to be refused")
+	}
+}
@@ -0,0 +1,87 @@
+package cloudflare
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/stupside/moley/v2/internal/domain"
+	"github.com/stupside/moley/v2/internal/platform/metrics"
+)
+
+// MetricsTargets lists every tunnel with a configuration in ~/.moley/tunnels.
+func MetricsTargets() ([]metrics.Target, error) {
+	folder, err := tunnelsFolderPath()
+	if err != nil {
+		return nil, err
+	}
+
+	configs, err := filepath.Glob(filepath.Join(folder, "*.yml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tunnel configurations: %w", err)
+	}
+
+	targets := make([]metrics.Target, 0, len(configs))
+	for _, path := range configs {
+		name := strings.TrimSuffix(filepath.Base(path), ".yml")
+		address, err := readMetricsAddress(filepath.Join(folder, name+".metrics.addr"))
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, metrics.Target{
+			Tunnel:    name,
+			Address:   address,
+			StatePath: filepath.Join(folder, name+".metrics.json"),
+		})
+	}
+	return targets, nil
+}
+
+// MetricsStatePath returns the file holding Moley's counters for tunnel.
+func MetricsStatePath(tunnel *domain.Tunnel) (string, error) {
+	folder, err := tunnelsFolderPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(folder, tunnel.GetName()+".metrics.json"), nil
+}
+
+// metricsAddressPath returns the file holding the metrics address of the
+// tunnel's most recently started cloudflared.
+func metricsAddressPath(tunnel *domain.Tunnel) (string, error) {
+	folder, err := tunnelsFolderPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(folder, tunnel.GetName()+".metrics.addr"), nil
+}
+
+// readMetricsAddress reads the address saved at path; a missing file is no address.
+func readMetricsAddress(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read tunnel metrics address: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// allocateMetricsAddress returns a free local address for one cloudflared
+// process. Every process gets its own, so a replacement started before the old
+// process stops does not fail to bind the old one's port.
+func allocateMetricsAddress() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("failed to find a free port: %w", err)
+	}
+	addr := listener.Addr().String()
+	if err := listener.Close(); err != nil {
+		return "", fmt.Errorf("failed to release port: %w", err)
+	}
+	return addr, nil
+}
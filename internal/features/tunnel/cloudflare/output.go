@@ -0,0 +1,104 @@
+package cloudflare
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/rs/zerolog"
+
+	logger "github.com/stupside/moley/v2/internal/platform/logging"
+)
+
+// outputMaxLine bounds how much of an unterminated line is buffered before it
+// is emitted anyway.
+const outputMaxLine = 64 * 1024
+
+// outputStream receives cloudflared's stdout and stderr, splits it into lines,
+// and re-emits every line through Moley's logger. Lines are also appended to
+// file, when set, exactly as cloudflared wrote them.
+type outputStream struct {
+	file io.Writer
+	buf  []byte
+}
+
+func newOutputStream(file io.Writer) *outputStream {
+	return &outputStream{file: file}
+}
+
+func (s *outputStream) Write(p []byte) (int, error) {
+	s.buf = append(s.buf, p...)
+
+	start := 0
+	for {
+		i := bytes.IndexByte(s.buf[start:], '\n')
+		if i < 0 {
+			break
+		}
+		s.emit(s.buf[start : start+i])
+		start += i + 1
+	}
+	s.buf = s.buf[:copy(s.buf, s.buf[start:])]
+
+	if len(s.buf) > outputMaxLine {
+		s.flush()
+	}
+	return len(p), nil
+}
+
+// flush emits a trailing line that was not newline-terminated, e.g. when the
+// process exits mid-write.
+func (s *outputStream) flush() {
+	if len(s.buf) > 0 {
+		s.emit(s.buf)
+		s.buf = s.buf[:0]
+	}
+}
+
+func (s *outputStream) emit(line []byte) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return
+	}
+
+	if s.file != nil {
+		// One write per line keeps records whole when processes share the file.
+		_, _ = s.file.Write(append(bytes.Clone(line), '\n'))
+	}
+
+	level, msg, fields := parseOutputLine(line)
+	logger.Log(level, msg, fields)
+}
+
+// parseOutputLine decodes a cloudflared JSON log record. Anything that is not
+// one (banners, panics) is relayed verbatim at info level.
+func parseOutputLine(line []byte) (zerolog.Level, string, map[string]any) {
+	var record map[string]any
+	if err := json.Unmarshal(line, &record); err != nil {
+		return zerolog.InfoLevel, string(line), map[string]any{"source": "cloudflared"}
+	}
+
+	msg, _ := record["message"].(string)
+	levelName, _ := record["level"].(string)
+
+	// Moley stamps its own time; the rest of the record is kept as fields.
+	delete(record, "message")
+	delete(record, "level")
+	delete(record, "time")
+	record["source"] = "cloudflared"
+
+	return outputLevel(levelName), msg, record
+}
+
+// outputLevel maps a cloudflared level onto Moley's. Fatal and panic records are
+// downgraded to errors: cloudflared exiting is the supervisor's business.
+func outputLevel(name string) zerolog.Level {
+	level, err := zerolog.ParseLevel(name)
+	if err != nil || level == zerolog.NoLevel {
+		return zerolog.InfoLevel
+	}
+	if level > zerolog.ErrorLevel {
+		return zerolog.ErrorLevel
+	}
+	return level
+}
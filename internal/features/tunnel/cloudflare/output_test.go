@@ -0,0 +1,76 @@
+package cloudflare
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestOutputStreamSplitsLines(t *testing.T) {
+	var file bytes.Buffer
+	s := newOutputStream(&file)
+
+	for _, chunk := range []string{"hel", "lo\nwor", "ld\n\n  \nrest"} {
+		if n, err := s.Write([]byte(chunk)); err != nil || n != len(chunk) {
+			t.Fatalf("Write(%q) = %d, %v", chunk, n, err)
+		}
+	}
+	if got := file.String(); got != "hello\nworld\n" {
+		t.Errorf("expected complete lines only, without blank ones, got %q", got)
+	}
+
+	s.flush()
+	if got := file.String(); got != "hello\nworld\nrest\n" {
+		t.Errorf("expected flush to emit the partial line, got %q", got)
+	}
+}
+
+func TestOutputStreamEmitsOverlongLine(t *testing.T) {
+	var file bytes.Buffer
+	s := newOutputStream(&file)
+
+	long := strings.Repeat("x", outputMaxLine+1)
+	if _, err := s.Write([]byte(long)); err != nil {
+		t.Fatal(err)
+	}
+	if got := file.String(); got != long+"\n" {
+		t.Errorf("expected a line past the limit to be emitted unterminated, got %d bytes", len(got))
+	}
+}
+
+func TestParseOutputLine(t *testing.T) {
+	tests := []struct {
+		name      string
+		line      string
+		wantLevel zerolog.Level
+		wantMsg   string
+	}{
+		{name: "info", line: `{"level":"info","message":"Registered tunnel connection","connIndex":0}`, wantLevel: zerolog.InfoLevel, wantMsg: "Registered tunnel connection"},
+		{name: "debug", line: `{"level":"debug","message":"probe"}`, wantLevel: zerolog.DebugLevel, wantMsg: "probe"},
+		{name: "warn", line: `{"level":"warn","message":"retrying"}`, wantLevel: zerolog.WarnLevel, wantMsg: "retrying"},
+		{name: "fatal is downgraded", line: `{"level":"fatal","message":"bye"}`, wantLevel: zerolog.ErrorLevel, wantMsg: "bye"},
+		{name: "panic is downgraded", line: `{"level":"panic","message":"boom"}`, wantLevel: zerolog.ErrorLevel, wantMsg: "boom"},
+		{name: "unknown level", line: `{"level":"verbose","message":"odd"}`, wantLevel: zerolog.InfoLevel, wantMsg: "odd"},
+		{name: "missing level", line: `{"message":"bare"}`, wantLevel: zerolog.InfoLevel, wantMsg: "bare"},
+		{name: "not json", line: `2026-01-01 cloudflared banner`, wantLevel: zerolog.InfoLevel, wantMsg: "2026-01-01 cloudflared banner"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level, msg, fields := parseOutputLine([]byte(tt.line))
+			if level != tt.wantLevel || msg != tt.wantMsg {
+				t.Errorf("got level %s and message %q, want %s and %q", level, msg, tt.wantLevel, tt.wantMsg)
+			}
+			if fields["source"] != "cloudflared" {
+				t.Errorf("expected the cloudflared source field, got %v", fields)
+			}
+			for _, key := range []string{"level", "message", "time"} {
+				if _, ok := fields[key]; ok {
+					t.Errorf("expected %s to be dropped from the fields, got %v", key, fields)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package cloudflare
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	tunnelusecase "github.com/stupside/moley/v2/internal/features/tunnel/usecase"
+	logger "github.com/stupside/moley/v2/internal/platform/logging"
+	sys "github.com/stupside/moley/v2/internal/platform/system"
+)
+
+const (
+	// supervisorMinBackoff is the delay before the first restart; it doubles on
+	// every consecutive crash up to supervisorMaxBackoff.
+	supervisorMinBackoff = time.Second
+	supervisorMaxBackoff = time.Minute
+	// supervisorStableAfter is how long a process must run before a crash is no
+	// longer counted against the budget of the previous ones.
+	supervisorStableAfter = 2 * time.Minute
+	// supervisorStopTimeout bounds how long Stop waits for cloudflared to exit.
+	supervisorStopTimeout = 10 * time.Second
+)
+
+// supervisorClock holds the delays of a supervisor and the clock it measures
+// them with, so tests can run it without waiting.
+type supervisorClock struct {
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	stableAfter time.Duration
+	stopTimeout time.Duration
+
+	now   func() time.Time
+	after func(time.Duration) <-chan time.Time
+}
+
+var defaultSupervisorClock = supervisorClock{
+	minBackoff:  supervisorMinBackoff,
+	maxBackoff:  supervisorMaxBackoff,
+	stableAfter: supervisorStableAfter,
+	stopTimeout: supervisorStopTimeout,
+	now:         time.Now,
+	after:       time.After,
+}
+
+// supervisor owns a cloudflared process, waits on it, and restarts it on
+// unexpected exit with exponential backoff until maxRestarts consecutive
+// crashes have been spent.
+type supervisor struct {
+	handle      int
+	args        []string
+	maxRestarts int
+	output      *outputStream
+	events      chan<- tunnelusecase.ProcessEvent
+	clock       supervisorClock
+	command     func(args ...string) *cloudflaredCmd
+
+	mu     sync.Mutex
+	cmd    *cloudflaredCmd
+	status tunnelusecase.ProcessStatus
+
+	stopOnce sync.Once
+	stop     chan struct{} // closed by Stop
+	done     chan struct{} // closed when run returns
+}
+
+// startSupervisor starts cloudflared and supervises it in the background.
+// The PID of the first process is the supervisor's handle. The output of every
+// process is relayed through the logger and, when logFile is set, appended to it.
+func startSupervisor(args []string, maxRestarts int, logFile io.Writer, events chan<- tunnelusecase.ProcessEvent) (*supervisor, error) {
+	s := newSupervisor(args, maxRestarts, logFile, events)
+	if err := s.start(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// newSupervisor returns a supervisor of cloudflared that has not started yet.
+func newSupervisor(args []string, maxRestarts int, logFile io.Writer, events chan<- tunnelusecase.ProcessEvent) *supervisor {
+	return &supervisor{
+		args:        args,
+		maxRestarts: maxRestarts,
+		output:      newOutputStream(logFile),
+		events:      events,
+		clock:       defaultSupervisorClock,
+		command: func(args ...string) *cloudflaredCmd {
+			return newCommand(context.Background(), args...)
+		},
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// start spawns the first process and supervises it in the background.
+func (s *supervisor) start() error {
+	cmd, err := s.spawn()
+	if err != nil {
+		return err
+	}
+	s.handle = cmd.cmd.Process.Pid
+
+	go s.run()
+	return nil
+}
+
+// spawn starts a new cloudflared process and makes it the current one. The process
+// is not bound to a context: only Stop terminates it, so a cancelled reconcile
+// pass does not kill the tunnel.
+func (s *supervisor) spawn() (*cloudflaredCmd, error) {
+	cmd := s.command(s.args...)
+	cmd.cmd.Stdout = s.output
+	cmd.cmd.Stderr = s.output
+
+	pid, err := cmd.execAsync()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cmd = cmd
+	s.status.PID = pid
+	s.status.StartedAt = time.Now()
+	s.status.Running = true
+	s.mu.Unlock()
+
+	return cmd, nil
+}
+
+func (s *supervisor) run() {
+	defer close(s.done)
+
+	backoff := s.clock.minBackoff
+	crashes := 0
+
+	for {
+		s.mu.Lock()
+		cmd := s.cmd
+		s.mu.Unlock()
+
+		startedAt := s.clock.now()
+		waitErr := cmd.cmd.Wait()
+		s.output.flush()
+		exitCode := cmd.cmd.ProcessState.ExitCode()
+
+		s.mu.Lock()
+		s.status.Running = false
+		s.status.ExitCode = &exitCode
+		s.mu.Unlock()
+
+		if s.stopped() {
+			return
+		}
+
+		if s.clock.now().Sub(startedAt) >= s.clock.stableAfter {
+			crashes, backoff = 0, s.clock.minBackoff
+		}
+
+		fields := map[string]any{
+			"pid":       cmd.cmd.Process.Pid,
+			"exit_code": exitCode,
+			"crashes":   crashes,
+		}
+		if waitErr != nil {
+			fields["error"] = waitErr.Error()
+		}
+
+		if crashes >= s.maxRestarts {
+			s.mu.Lock()
+			s.status.Failed = true
+			s.mu.Unlock()
+
+			err := fmt.Errorf("cloudflared exited with code %d and the restart budget of %d is exhausted", exitCode, s.maxRestarts)
+			logger.Errorf("Tunnel process keeps crashing, giving up", fields)
+			s.notify(err)
+			return
+		}
+
+		fields["backoff"] = backoff.String()
+		logger.Warnf("Tunnel process exited unexpectedly, restarting", fields)
+
+		select {
+		case <-s.clock.after(backoff):
+		case <-s.stop:
+			return
+		}
+		backoff = min(backoff*2, s.clock.maxBackoff)
+		crashes++
+
+		next, err := s.spawn()
+		if err != nil {
+			s.mu.Lock()
+			s.status.Failed = true
+			s.mu.Unlock()
+
+			logger.Errorf("Failed to restart tunnel process", map[string]any{"error": err.Error()})
+			s.notify(fmt.Errorf("failed to restart cloudflared: %w", err))
+			return
+		}
+		if s.stopped() {
+			// Stop ran while the replacement was starting and may have missed it.
+			_ = sys.TerminateProcess(next.cmd.Process)
+			continue
+		}
+
+		s.mu.Lock()
+		s.status.Restarts++
+		status := s.status
+		s.mu.Unlock()
+
+		logger.Infof("Tunnel process restarted", map[string]any{"pid": status.PID, "restarts": status.Restarts})
+		s.notify(nil)
+	}
+}
+
+func (s *supervisor) stopped() bool {
+	select {
+	case <-s.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// notify publishes the current status without blocking the supervisor.
+func (s *supervisor) notify(err error) {
+	event := tunnelusecase.ProcessEvent{Handle: s.handle, Status: s.Status(), Err: err}
+	select {
+	case s.events <- event:
+	default:
+		logger.Debugf("Dropped tunnel process event, nobody is listening", map[string]any{"handle": s.handle})
+	}
+}
+
+// Status returns a copy of the current process status.
+func (s *supervisor) Status() tunnelusecase.ProcessStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// Stop ends supervision and terminates the current process, killing it when it
+// has not exited within the stop timeout.
+func (s *supervisor) Stop(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stop) })
+
+	s.mu.Lock()
+	cmd, running := s.cmd, s.status.Running
+	s.mu.Unlock()
+
+	if running {
+		if err := sys.TerminateProcess(cmd.cmd.Process); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			return err
+		}
+	}
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.clock.after(s.clock.stopTimeout):
+	}
+
+	// The supervisor may have started a replacement since cmd was read.
+	s.mu.Lock()
+	cmd = s.cmd
+	s.mu.Unlock()
+
+	logger.Warnf("Tunnel process did not exit, killing it", map[string]any{
+		"pid":     cmd.cmd.Process.Pid,
+		"timeout": s.clock.stopTimeout.String(),
+	})
+	if err := cmd.cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("failed to kill cloudflared (pid %d): %w", cmd.cmd.Process.Pid, err)
+	}
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
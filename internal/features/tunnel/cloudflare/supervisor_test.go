@@ -0,0 +1,165 @@
+//go:build !windows
+
+package cloudflare
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	tunnelusecase "github.com/stupside/moley/v2/internal/features/tunnel/usecase"
+)
+
+// fakeClock hands out a fixed time step on every reading and records the
+// backoffs the supervisor waits for, returning immediately.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	step   time.Duration
+	waited []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(c.step)
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	c.waited = append(c.waited, d)
+	c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	ch <- time.Time{}
+	return ch
+}
+
+func (c *fakeClock) Waited() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.waited)
+}
+
+// newTestSupervisor supervises sh running script in place of cloudflared.
+func newTestSupervisor(t *testing.T, script string, maxRestarts int, clock *fakeClock) (*supervisor, <-chan tunnelusecase.ProcessEvent) {
+	t.Helper()
+
+	events := make(chan tunnelusecase.ProcessEvent, 64)
+	s := newSupervisor(nil, maxRestarts, nil, events)
+	s.command = func(...string) *cloudflaredCmd {
+		return &cloudflaredCmd{cmd: exec.Command("sh", "-c", script)}
+	}
+	s.clock = supervisorClock{
+		minBackoff:  time.Second,
+		maxBackoff:  4 * time.Second,
+		stableAfter: 2 * time.Minute,
+		stopTimeout: 100 * time.Millisecond,
+		now:         clock.Now,
+		after:       clock.After,
+	}
+
+	if err := s.start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Stop(context.Background()) })
+	return s, events
+}
+
+// waitEvent returns the next process event, failing the test after a while.
+func waitEvent(t *testing.T, events <-chan tunnelusecase.ProcessEvent) tunnelusecase.ProcessEvent {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for a process event")
+		return tunnelusecase.ProcessEvent{}
+	}
+}
+
+func TestSupervisorGivesUpWhenBudgetIsSpent(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	s, events := newTestSupervisor(t, "exit 3", 4, clock)
+
+	for i := range 4 {
+		if event := waitEvent(t, events); event.Err != nil {
+			t.Fatalf("restart %d: unexpected error %v", i+1, event.Err)
+		}
+	}
+	final := waitEvent(t, events)
+	if final.Err == nil {
+		t.Fatal("expected the supervisor to give up after the restart budget")
+	}
+
+	status := s.Status()
+	if !status.Failed || status.Restarts != 4 || status.ExitCode == nil || *status.ExitCode != 3 {
+		t.Errorf("expected a failed process with 4 restarts and exit code 3, got %+v", status)
+	}
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second}
+	if got := clock.Waited(); !slices.Equal(got, want) {
+		t.Errorf("expected backoffs %v, got %v", want, got)
+	}
+}
+
+func TestSupervisorResetsBudgetAfterStableRun(t *testing.T) {
+	// Every run lasts as long as the stable period, so no crash is counted.
+	clock := &fakeClock{now: time.Unix(0, 0), step: 2 * time.Minute}
+	s, events := newTestSupervisor(t, "exit 1", 1, clock)
+
+	for i := range 5 {
+		if event := waitEvent(t, events); event.Err != nil {
+			t.Fatalf("restart %d: expected the budget to be reset, got %v", i+1, event.Err)
+		}
+	}
+
+	// Each of the five restarts waited for a backoff before its event.
+	if got := clock.Waited()[:5]; slices.ContainsFunc(got, func(d time.Duration) bool { return d != time.Second }) {
+		t.Errorf("expected every backoff to restart from the minimum, got %v", got)
+	}
+	if s.Status().Failed {
+		t.Error("expected the supervisor not to give up")
+	}
+}
+
+func TestSupervisorStopKillsProcessIgnoringTerm(t *testing.T) {
+	ready := filepath.Join(t.TempDir(), "ready")
+
+	events := make(chan tunnelusecase.ProcessEvent, 1)
+	s := newSupervisor(nil, 0, nil, events)
+	s.command = func(...string) *cloudflaredCmd {
+		return &cloudflaredCmd{cmd: exec.Command("sh", "-c", `trap "" TERM; touch "$0"; while :; do :; done`, ready)}
+	}
+	s.clock = defaultSupervisorClock
+	s.clock.stopTimeout = 100 * time.Millisecond
+
+	if err := s.start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	for deadline := time.Now().Add(10 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if _, err := os.Stat(ready); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the process to ignore SIGTERM")
+		}
+	}
+
+	started := time.Now()
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed < 100*time.Millisecond {
+		t.Errorf("expected Stop to wait for the timeout before killing, returned after %v", elapsed)
+	}
+	if s.Status().Running {
+		t.Error("expected the process to be gone after Stop")
+	}
+}
@@ -7,29 +7,80 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sync"
 
 	cfgo "github.com/cloudflare/cloudflare-go/v3"
 	"github.com/cloudflare/cloudflare-go/v3/zero_trust"
 	"github.com/cloudflare/cloudflare-go/v3/zones"
 
 	"github.com/stupside/moley/v2/internal/domain"
+	tunnelusecase "github.com/stupside/moley/v2/internal/features/tunnel/usecase"
 	logger "github.com/stupside/moley/v2/internal/platform/logging"
 	"github.com/stupside/moley/v2/internal/platform/paths"
 	"go.yaml.in/yaml/v3"
 )
 
+const (
+	// logFileMaxSize and logFileBackups bound the disk used by a tunnel's log file.
+	logFileMaxSize = 10 << 20
+	logFileBackups = 3
+)
+
 type TunnelService struct {
 	client    *cfgo.Client
 	accountID string
 	dryRun    bool
+
+	// supervise makes Run restart cloudflared on unexpected exit, up to maxRestarts
+	// consecutive times. Unsupervised processes outlive moley (detached mode).
+	supervise   bool
+	maxRestarts int
+	events      chan tunnelusecase.ProcessEvent
+
+	// logFile makes supervised runs also append cloudflared output to the
+	// tunnel's rotating log file.
+	logFile bool
+
+	mu          sync.Mutex
+	supervisors map[int]*supervisor
+	logFiles    map[string]*logger.RotatingFile
+}
+
+// Option configures optional TunnelService behaviour.
+type Option func(*TunnelService)
+
+// WithSupervision keeps cloudflared as a supervised child process, restarted with
+// exponential backoff until maxRestarts consecutive crashes.
+func WithSupervision(maxRestarts int) Option {
+	return func(c *TunnelService) {
+		c.supervise = true
+		c.maxRestarts = maxRestarts
+	}
 }
 
-func NewTunnelService(ctx context.Context, client *cfgo.Client, zoneName string, dryRun bool) (*TunnelService, error) {
+// WithLogFile also writes the output of supervised cloudflared processes to
+// ~/.moley/tunnels/<name>.log, rotated at logFileMaxSize.
+func WithLogFile() Option {
+	return func(c *TunnelService) {
+		c.logFile = true
+	}
+}
+
+// NewTunnelService creates a tunnel service for the account owning zoneNames,
+// which must all belong to the same account.
+func NewTunnelService(ctx context.Context, client *cfgo.Client, zoneNames []string, dryRun bool, opts ...Option) (*TunnelService, error) {
 	svc := &TunnelService{
-		client: client,
-		dryRun: dryRun,
+		client:      client,
+		dryRun:      dryRun,
+		events:      make(chan tunnelusecase.ProcessEvent, 16),
+		supervisors: make(map[int]*supervisor),
+		logFiles:    make(map[string]*logger.RotatingFile),
+	}
+	for _, opt := range opts {
+		opt(svc)
 	}
 
 	if svc.dryRun {
@@ -37,11 +88,16 @@ func NewTunnelService(ctx context.Context, client *cfgo.Client, zoneName string,
 		return svc, nil
 	}
 
-	accountID, err := svc.resolveAccountID(ctx, zoneName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to resolve account ID from zone %s: %w", zoneName, err)
+	for _, zoneName := range zoneNames {
+		accountID, err := svc.resolveAccountID(ctx, zoneName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve account ID from zone %s: %w", zoneName, err)
+		}
+		if svc.accountID != "" && accountID != svc.accountID {
+			return nil, fmt.Errorf("zone %s belongs to another account than zone %s; a tunnel can only serve zones of one account", zoneName, zoneNames[0])
+		}
+		svc.accountID = accountID
 	}
-	svc.accountID = accountID
 
 	return svc, nil
 }
@@ -63,15 +119,17 @@ func (c *TunnelService) AccountID() string {
 	return c.accountID
 }
 
-func (c *TunnelService) Run(ctx context.Context, tunnel *domain.Tunnel) (int, error) {
+// Run starts cloudflared and returns its handle and the address of its metrics
+// endpoint, picked for this process.
+func (c *TunnelService) Run(ctx context.Context, tunnel *domain.Tunnel) (int, string, error) {
 	if c.dryRun {
 		logger.Debug("Dry run: skipping tunnel process start")
-		return 0, nil
+		return 0, "", nil
 	}
 
 	configPath, err := c.GetConfigurationPath(ctx, tunnel)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get tunnel configuration path: %w", err)
+		return 0, "", fmt.Errorf("failed to get tunnel configuration path: %w", err)
 	}
 
 	// Pass the UUID (not the name) so cloudflared doesn't do a name→UUID
@@ -79,15 +137,152 @@ func (c *TunnelService) Run(ctx context.Context, tunnel *domain.Tunnel) (int, er
 	// from `cloudflared tunnel login`.
 	tunnelUUID, err := c.GetID(ctx, tunnel)
 	if err != nil {
-		return 0, fmt.Errorf("failed to resolve tunnel UUID: %w", err)
+		return 0, "", fmt.Errorf("failed to resolve tunnel UUID: %w", err)
+	}
+
+	logPath, err := c.GetLogPath(ctx, tunnel)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to get tunnel log path: %w", err)
+	}
+
+	metrics, err := allocateMetricsAddress()
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to allocate metrics address: %w", err)
+	}
+
+	var handle int
+	if !c.supervise {
+		// Nothing reads the output of a process that outlives moley, so
+		// cloudflared writes its log file itself.
+		args := []string{"tunnel", "--config", configPath, "--metrics", metrics, "--logfile", logPath, "run", tunnelUUID}
+		handle, err = newCommand(ctx, args...).execAsync()
+		if err != nil {
+			return 0, "", fmt.Errorf("failed to run tunnel: %w", err)
+		}
+	} else {
+		// --output selects cloudflared's log format; JSON records are relayed with
+		// their level and fields intact.
+		args := []string{"tunnel", "--config", configPath, "--metrics", metrics, "--output", "json", "run", tunnelUUID}
+
+		var logFile io.Writer
+		if c.logFile {
+			file, err := c.openLogFile(logPath)
+			if err != nil {
+				return 0, "", err
+			}
+			logFile = file
+		}
+
+		sup, err := startSupervisor(args, c.maxRestarts, logFile, c.events)
+		if err != nil {
+			return 0, "", fmt.Errorf("failed to run tunnel: %w", err)
+		}
+
+		c.mu.Lock()
+		c.supervisors[sup.handle] = sup
+		c.mu.Unlock()
+
+		handle = sup.handle
+	}
+
+	// `moley metrics` scrapes the newest process of each tunnel.
+	if err := c.saveMetricsAddress(tunnel, metrics); err != nil {
+		logger.Warnf("Failed to save tunnel metrics address", map[string]any{"error": err.Error()})
+	}
+
+	return handle, metrics, nil
+}
+
+// saveMetricsAddress records where the tunnel's newest cloudflared serves its metrics.
+func (c *TunnelService) saveMetricsAddress(tunnel *domain.Tunnel, address string) error {
+	path, err := metricsAddressPath(tunnel)
+	if err != nil {
+		return err
 	}
+	return os.WriteFile(path, []byte(address+"\n"), 0600)
+}
+
+// openLogFile returns the shared rotating log file for path, so processes of the
+// same tunnel (e.g. during a restart on reload) append to one file.
+func (c *TunnelService) openLogFile(path string) (*logger.RotatingFile, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	cfCommand := newCommand(ctx, "tunnel", "--config", configPath, "run", tunnelUUID)
-	pid, err := cfCommand.execAsync()
+	if file, ok := c.logFiles[path]; ok {
+		return file, nil
+	}
+	file, err := logger.OpenRotatingFile(path, logFileMaxSize, logFileBackups)
 	if err != nil {
-		return 0, fmt.Errorf("failed to run tunnel: %w", err)
+		return nil, fmt.Errorf("failed to open tunnel log file: %w", err)
 	}
-	return pid, nil
+	c.logFiles[path] = file
+	return file, nil
+}
+
+// Stop terminates a supervised tunnel process and ends its supervision.
+func (c *TunnelService) Stop(ctx context.Context, handle int) (bool, error) {
+	c.mu.Lock()
+	sup, ok := c.supervisors[handle]
+	delete(c.supervisors, handle)
+	c.mu.Unlock()
+
+	if !ok {
+		return false, nil
+	}
+	return true, sup.Stop(ctx)
+}
+
+// Status reports on a supervised tunnel process.
+func (c *TunnelService) Status(handle int) (tunnelusecase.ProcessStatus, bool) {
+	c.mu.Lock()
+	sup, ok := c.supervisors[handle]
+	c.mu.Unlock()
+
+	if !ok {
+		return tunnelusecase.ProcessStatus{}, false
+	}
+	return sup.Status(), true
+}
+
+// Events delivers restarts and failures of supervised tunnel processes.
+func (c *TunnelService) Events() <-chan tunnelusecase.ProcessEvent {
+	return c.events
+}
+
+// Connections counts the tunnel's edge connections that are actively serving
+// traffic. Connections Cloudflare still tracks after a disconnect are ignored.
+func (c *TunnelService) Connections(ctx context.Context, tunnel *domain.Tunnel) (int, error) {
+	if c.dryRun {
+		return 1, nil
+	}
+
+	tunnelID, err := c.GetID(ctx, tunnel)
+	if err != nil {
+		return 0, err
+	}
+
+	clients, err := c.client.ZeroTrust.Tunnels.Connections.Get(ctx, tunnelID, zero_trust.TunnelConnectionGetParams{
+		AccountID: cfgo.F(c.accountID),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list tunnel connections: %w", err)
+	}
+
+	active := 0
+	for _, client := range *clients {
+		for _, conn := range client.Conns {
+			if !conn.IsPendingReconnect {
+				active++
+			}
+		}
+	}
+
+	logger.Debugf("Tunnel connections", map[string]any{
+		"tunnelID":   tunnelID,
+		"connectors": len(*clients),
+		"active":     active,
+	})
+	return active, nil
 }
 
 // findTunnel looks up a tunnel by name and returns its UUID, or empty string if not found.
@@ -281,25 +476,28 @@ func (c *TunnelService) SaveConfiguration(ctx context.Context, tunnel *domain.Tu
 		"path": credentialsFile,
 	})
 
+	path, err := c.GetConfigurationPath(ctx, tunnel)
+	if err != nil {
+		return fmt.Errorf("failed to get tunnel configuration path: %w", err)
+	}
+
 	// Store the UUID (not the name) in the config so cloudflared never needs
 	// to resolve a name via the control plane (which would require cert.pem).
 	config := &runConfig{
 		Tunnel:          tunnelUUID,
-		Logfile:         "cloudflared.log",
 		Ingress:         nil,
 		Loglevel:        "info",
 		CredentialsFile: credentialsFile,
+		OriginRequest:   newOriginRequest(ingress.Origin),
 	}
 
 	logger.Infof("Building ingress rules", map[string]any{
-		"apps": len(ingress.Apps),
-		"zone": ingress.Zone,
+		"apps":  len(ingress.Apps),
+		"zones": ingress.Zones(),
 	})
-	for _, app := range ingress.Apps {
-		config.Ingress = append(config.Ingress, ingressRule{
-			Service:  app.Target.GetTargetURL(),
-			Hostname: domain.FQDN(app.Expose.Subdomain, ingress.Zone),
-		})
+	config.Ingress, err = ingressRules(ingress)
+	if err != nil {
+		return fmt.Errorf("failed to build ingress rules: %w", err)
 	}
 
 	logger.Info("Adding catch-all ingress rule")
@@ -312,11 +510,6 @@ func (c *TunnelService) SaveConfiguration(ctx context.Context, tunnel *domain.Tu
 		return fmt.Errorf("failed to marshal configuration: %w", err)
 	}
 
-	path, err := c.GetConfigurationPath(ctx, tunnel)
-	if err != nil {
-		return fmt.Errorf("failed to get tunnel configuration path: %w", err)
-	}
-
 	if err := os.WriteFile(path, bytes, 0600); err != nil {
 		return fmt.Errorf("failed to save tunnel configuration: %w", err)
 	}
@@ -333,6 +526,13 @@ func (c *TunnelService) DeleteConfiguration(ctx context.Context, tunnel *domain.
 		return fmt.Errorf("failed to get tunnel configuration path: %w", err)
 	}
 
+	// The metrics address only matters while the tunnel has a configuration.
+	if addressPath, err := metricsAddressPath(tunnel); err == nil {
+		if err := os.Remove(addressPath); err != nil && !os.IsNotExist(err) {
+			logger.Warnf("Failed to delete tunnel metrics address", map[string]any{"error": err.Error()})
+		}
+	}
+
 	if err := os.Remove(path); err != nil {
 		if os.IsNotExist(err) {
 			logger.Debug("Configuration file does not exist, skipping deletion")
@@ -370,14 +570,9 @@ func (c *TunnelService) Exists(ctx context.Context, tunnel *domain.Tunnel) (bool
 func (c *TunnelService) GetConfigurationPath(ctx context.Context, tunnel *domain.Tunnel) (string, error) {
 	logger.Debug("Getting tunnel configuration path")
 
-	base, err := paths.GetUserFolderPath()
+	tunnelsFolder, err := tunnelsFolderPath()
 	if err != nil {
-		return "", fmt.Errorf("failed to get user folder path: %w", err)
-	}
-
-	tunnelsFolder := filepath.Join(base, "tunnels")
-	if err := os.MkdirAll(tunnelsFolder, 0755); err != nil {
-		return "", fmt.Errorf("failed to create tunnels directory: %w", err)
+		return "", err
 	}
 
 	tunnelFile := filepath.Join(tunnelsFolder, tunnel.GetName()+".yml")
@@ -388,3 +583,26 @@ func (c *TunnelService) GetConfigurationPath(ctx context.Context, tunnel *domain
 
 	return tunnelFile, nil
 }
+
+// GetLogPath returns the path of the tunnel's cloudflared log file.
+func (c *TunnelService) GetLogPath(ctx context.Context, tunnel *domain.Tunnel) (string, error) {
+	tunnelsFolder, err := tunnelsFolderPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(tunnelsFolder, tunnel.GetName()+".log"), nil
+}
+
+// tunnelsFolderPath returns ~/.moley/tunnels, creating it if needed.
+func tunnelsFolderPath() (string, error) {
+	base, err := paths.GetUserFolderPath()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user folder path: %w", err)
+	}
+
+	tunnelsFolder := filepath.Join(base, "tunnels")
+	if err := os.MkdirAll(tunnelsFolder, 0755); err != nil {
+		return "", fmt.Errorf("failed to create tunnels directory: %w", err)
+	}
+	return tunnelsFolder, nil
+}
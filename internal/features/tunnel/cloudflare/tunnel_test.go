@@ -0,0 +1,82 @@
+package cloudflare
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	cfgo "github.com/cloudflare/cloudflare-go/v3"
+	"github.com/cloudflare/cloudflare-go/v3/option"
+)
+
+// zonesAPI serves the zone list of a Cloudflare API with the given zones, each
+// mapped to the ID of the account owning it.
+func zonesAPI(t *testing.T, accounts map[string]string) *cfgo.Client {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/zones" {
+			http.NotFound(w, r)
+			return
+		}
+		query := r.URL.Query()
+		result := []any{}
+		// Every list fits on the first page; later pages end auto-paging.
+		if page := query.Get("page"); page == "" || page == "1" {
+			name := query.Get("name")
+			if account, ok := accounts[name]; ok {
+				result = append(result, map[string]any{"id": "zone-" + name, "name": name, "account": map[string]string{"id": account}})
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"success": true, "errors": []any{}, "messages": []any{}, "result": result})
+	}))
+	t.Cleanup(srv.Close)
+
+	return cfgo.NewClient(
+		option.WithBaseURL(srv.URL+"/"),
+		option.WithAPIToken("token"),
+		option.WithMaxRetries(0),
+	)
+}
+
+func TestNewTunnelServiceResolvesAccountOfEveryZone(t *testing.T) {
+	client := zonesAPI(t, map[string]string{
+		"example.com": "account-a",
+		"example.org": "account-a",
+		"example.net": "account-b",
+	})
+
+	tests := []struct {
+		name        string
+		zones       []string
+		wantAccount string
+		wantErr     string
+	}{
+		{name: "single zone", zones: []string{"example.com"}, wantAccount: "account-a"},
+		{name: "zones of one account", zones: []string{"example.com", "example.org"}, wantAccount: "account-a"},
+		{name: "zones of two accounts", zones: []string{"example.com", "example.org", "example.net"}, wantErr: "zone example.net belongs to another account"},
+		{name: "unknown zone", zones: []string{"example.com", "missing.dev"}, wantErr: `zone "missing.dev" not found`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, err := NewTunnelService(context.Background(), client, tt.zones, false)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if svc.AccountID() != tt.wantAccount {
+				t.Errorf("expected account %s, got %s", tt.wantAccount, svc.AccountID())
+			}
+		})
+	}
+}
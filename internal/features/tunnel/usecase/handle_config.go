@@ -41,7 +41,11 @@ type configHandler struct {
 	tunnelService TunnelConfigurator
 }
 
-var _ framework.Lifecycle[ConfigInput, ConfigOutput] = (*configHandler)(nil)
+var (
+	_ framework.Lifecycle[ConfigInput, ConfigOutput] = (*configHandler)(nil)
+	_ framework.Updater[ConfigInput, ConfigOutput]   = (*configHandler)(nil)
+	_ framework.Describer[ConfigInput, ConfigOutput] = (*configHandler)(nil)
+)
 
 func NewConfigHandler(tunnelService TunnelConfigurator) *configHandler {
 	return &configHandler{
@@ -92,10 +96,21 @@ func (h *configHandler) Destroy(ctx context.Context, output ConfigOutput) error
 	return nil
 }
 
+// Update rewrites the configuration file in place. Destroying it first would
+// leave a running cloudflared without a config to reload from.
+func (h *configHandler) Update(ctx context.Context, _ framework.Snapshot[ConfigInput, ConfigOutput], input ConfigInput) (ConfigOutput, error) {
+	return h.Create(ctx, input)
+}
+
 func (h *configHandler) Check(ctx context.Context, output ConfigOutput) (framework.Status, error) {
 	return fileStatus(output.ConfigPath)
 }
 
+// Describe summarizes the cloudflared config file.
+func (h *configHandler) Describe(snap framework.Snapshot[ConfigInput, ConfigOutput]) string {
+	return fmt.Sprintf("config %s", snap.Output.ConfigPath)
+}
+
 func (h *configHandler) Recover(ctx context.Context, input ConfigInput) (ConfigOutput, framework.Status, error) {
 	tunnel := input.tunnel()
 
@@ -41,7 +41,12 @@ type createHandler struct {
 	tunnelService TunnelCreator
 }
 
-var _ framework.Lifecycle[CreateInput, CreateOutput] = (*createHandler)(nil)
+var (
+	_ framework.Lifecycle[CreateInput, CreateOutput] = (*createHandler)(nil)
+	_ framework.Updater[CreateInput, CreateOutput]   = (*createHandler)(nil)
+	_ framework.Describer[CreateInput, CreateOutput] = (*createHandler)(nil)
+	_ framework.Identifier[CreateOutput]             = (*createHandler)(nil)
+)
 
 func NewCreateHandler(tunnelService TunnelCreator) *createHandler {
 	return &createHandler{
@@ -89,10 +94,32 @@ func (h *createHandler) Destroy(ctx context.Context, output CreateOutput) error
 	return nil
 }
 
+// Update applies a persistence change without touching the tunnel itself.
+func (h *createHandler) Update(ctx context.Context, current framework.Snapshot[CreateInput, CreateOutput], input CreateInput) (CreateOutput, error) {
+	if current.Output.Name != input.Name || current.Output.TunnelUUID == "" {
+		return CreateOutput{}, framework.ErrReplaceRequired
+	}
+
+	logger.Infof("Tunnel persistence updated", map[string]any{"persistent": input.Persistent})
+	return CreateOutput{
+		Name:       input.Name,
+		Persistent: input.Persistent,
+		TunnelUUID: current.Output.TunnelUUID,
+	}, nil
+}
+
 func (h *createHandler) Check(ctx context.Context, output CreateOutput) (framework.Status, error) {
 	return h.checkExists(ctx, output.tunnel())
 }
 
+// Describe summarizes the tunnel by its UUID.
+func (h *createHandler) Describe(snap framework.Snapshot[CreateInput, CreateOutput]) string {
+	if snap.Output.Persistent {
+		return fmt.Sprintf("uuid %s (persistent)", snap.Output.TunnelUUID)
+	}
+	return fmt.Sprintf("uuid %s", snap.Output.TunnelUUID)
+}
+
 func (h *createHandler) Recover(ctx context.Context, input CreateInput) (CreateOutput, framework.Status, error) {
 	tunnel := input.tunnel()
 
@@ -123,3 +150,8 @@ func (h *createHandler) checkExists(ctx context.Context, tunnel *domain.Tunnel)
 	}
 	return framework.StatusDown, nil
 }
+
+// ID returns the UUID of the tunnel.
+func (h *createHandler) ID(_ context.Context, output CreateOutput) (string, error) {
+	return output.TunnelUUID, nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"syscall"
+	"time"
 
 	"github.com/stupside/moley/v2/internal/domain"
 	logger "github.com/stupside/moley/v2/internal/platform/logging"
@@ -13,10 +14,50 @@ import (
 	sys "github.com/stupside/moley/v2/internal/platform/system"
 )
 
+// ProcessStatus describes a tunnel process supervised by a TunnelRunner.
+type ProcessStatus struct {
+	PID       int
+	StartedAt time.Time // start of the current process
+	Running   bool
+	Restarts  int
+	ExitCode  *int // last exit code, nil until the process has exited once
+	Failed    bool // the supervisor gave up restarting the process
+}
+
+// ProcessEvent reports a restart of a supervised tunnel process. Err is set when
+// the supervisor gave up, after which the process is no longer restarted.
+type ProcessEvent struct {
+	Handle int
+	Status ProcessStatus
+	Err    error
+}
+
 type TunnelRunner interface {
-	Run(ctx context.Context, tunnel *domain.Tunnel) (int, error)
+	// Run starts the tunnel process and returns a handle to it, the PID of the
+	// first process, which stays stable across supervised restarts, and the
+	// address of its metrics endpoint, empty when it has none.
+	Run(ctx context.Context, tunnel *domain.Tunnel) (int, string, error)
+	// Stop terminates a supervised process. It returns false when handle is not
+	// supervised by this runner, e.g. a detached process from an earlier run.
+	Stop(ctx context.Context, handle int) (bool, error)
+	// Status reports on a supervised process; false when handle is not supervised here.
+	Status(handle int) (ProcessStatus, bool)
+	// Events delivers restarts and failures of supervised processes.
+	Events() <-chan ProcessEvent
+	// Connections returns the number of edge connections actively serving the
+	// tunnel, across all of its connectors.
+	Connections(ctx context.Context, tunnel *domain.Tunnel) (int, error)
 }
 
+// ErrNoConnections is reported by the run handler's Check when cloudflared is
+// alive but has not registered any edge connection, e.g. because of revoked
+// credentials or blocked egress.
+var ErrNoConnections = errors.New("cloudflared is running but has no edge connections")
+
+// connectionGracePeriod is how long a freshly started cloudflared has to register
+// its edge connections before Check expects to see them.
+const connectionGracePeriod = 30 * time.Second
+
 const RunHandlerName = "tunnel-run"
 
 type RunInput struct {
@@ -28,6 +69,14 @@ type RunInput struct {
 type RunOutput struct {
 	TunnelName string          `json:"tunnel_name"`
 	Process    processIdentity `json:"process"`
+	StartedAt  time.Time       `json:"started_at,omitzero"`
+	// MetricsAddress is where this cloudflared process serves its Prometheus metrics.
+	MetricsAddress string `json:"metrics_address,omitempty"`
+	// Supervisor is the handle of the supervisor owning the process, zero when
+	// the process runs detached. Restarts and LastExitCode are recorded from it.
+	Supervisor   int  `json:"supervisor,omitempty"`
+	Restarts     int  `json:"restarts,omitempty"`
+	LastExitCode *int `json:"last_exit_code,omitempty"`
 }
 
 // processIdentity tracks a process by both PID and command name to detect PID reuse.
@@ -40,7 +89,13 @@ type runHandler struct {
 	tunnelService TunnelRunner
 }
 
-var _ framework.Lifecycle[RunInput, RunOutput] = (*runHandler)(nil)
+var (
+	_ framework.Lifecycle[RunInput, RunOutput] = (*runHandler)(nil)
+	_ framework.CreateBeforeDestroyer          = (*runHandler)(nil)
+	_ framework.Refresher[RunOutput]           = (*runHandler)(nil)
+	_ framework.Describer[RunInput, RunOutput] = (*runHandler)(nil)
+	_ framework.DegradedReplacer               = (*runHandler)(nil)
+)
 
 func NewRunHandler(tunnelService TunnelRunner) *runHandler {
 	return &runHandler{
@@ -59,7 +114,9 @@ func (h *runHandler) Key(input RunInput) string {
 func (h *runHandler) Create(ctx context.Context, input RunInput) (RunOutput, error) {
 	logger.Debug("Starting tunnel process")
 
-	pid, err := h.tunnelService.Run(ctx, &domain.Tunnel{Name: input.TunnelName})
+	tunnel := &domain.Tunnel{Name: input.TunnelName}
+
+	pid, metrics, err := h.tunnelService.Run(ctx, tunnel)
 	if err != nil {
 		return RunOutput{}, fmt.Errorf("failed to start tunnel process: %w", err)
 	}
@@ -70,9 +127,34 @@ func (h *runHandler) Create(ctx context.Context, input RunInput) (RunOutput, err
 			PID:     pid,
 			Command: sys.GetProcessCommand(pid),
 		},
+		StartedAt:      time.Now(),
+		MetricsAddress: metrics,
+	}
+	if status, supervised := h.tunnelService.Status(pid); supervised {
+		output.Supervisor = pid
+		output.StartedAt = status.StartedAt
+	}
+
+	logger.Infof("Tunnel process started", map[string]any{"pid": pid, "supervised": output.Supervisor != 0})
+	return output, nil
+}
+
+// Refresh records the current PID, restart count, and exit code of a supervised process.
+func (h *runHandler) Refresh(ctx context.Context, output RunOutput) (RunOutput, error) {
+	status, ok := h.tunnelService.Status(output.Supervisor)
+	if output.Supervisor == 0 || !ok {
+		return output, nil
 	}
 
-	logger.Infof("Tunnel process started", map[string]any{"pid": pid})
+	if status.PID != output.Process.PID {
+		output.Process = processIdentity{
+			PID:     status.PID,
+			Command: sys.GetProcessCommand(status.PID),
+		}
+		output.StartedAt = status.StartedAt
+	}
+	output.Restarts = status.Restarts
+	output.LastExitCode = status.ExitCode
 	return output, nil
 }
 
@@ -83,6 +165,18 @@ func (h *runHandler) Destroy(ctx context.Context, output RunOutput) error {
 
 	logger.Debugf("Stopping tunnel process", map[string]any{"pid": output.Process.PID})
 
+	if output.Supervisor != 0 {
+		stopped, err := h.tunnelService.Stop(ctx, output.Supervisor)
+		if err != nil {
+			return fmt.Errorf("failed to stop supervised tunnel process: %w", err)
+		}
+		if stopped {
+			logger.Infof("Tunnel process stopped", map[string]any{"pid": output.Process.PID})
+			return nil
+		}
+		// Supervised by another moley process: fall back to signalling the PID.
+	}
+
 	process, err := os.FindProcess(output.Process.PID)
 	if err != nil {
 		logger.Warnf("Failed to find process, may have already exited", map[string]any{
@@ -104,6 +198,20 @@ func (h *runHandler) Destroy(ctx context.Context, output RunOutput) error {
 	return nil
 }
 
+// CreateBeforeDestroy starts the new cloudflared before stopping the old one.
+// Both connect to the same tunnel as replicas, so traffic keeps flowing while
+// the configuration rolls over.
+func (h *runHandler) CreateBeforeDestroy() bool {
+	return true
+}
+
+// ReplaceDegraded restarts a cloudflared that lost its edge connections. The
+// replacement starts before the old process stops, like a configuration change.
+// The framework gives up after a few restarts that did not bring them back.
+func (h *runHandler) ReplaceDegraded() bool {
+	return true
+}
+
 func isProcessNotFoundError(err error) bool {
 	var errno syscall.Errno
 	if errors.As(err, &errno) {
@@ -112,16 +220,69 @@ func isProcessNotFoundError(err error) bool {
 	return false
 }
 
+// Check reports a process as down once it has exited. A live process is only up
+// when Cloudflare sees at least one of the tunnel's edge connections; without
+// any, it is degraded with ErrNoConnections.
 func (h *runHandler) Check(ctx context.Context, output RunOutput) (framework.Status, error) {
 	if output.Process.PID == 0 {
 		return framework.StatusUp, nil // dry-run: no real process
 	}
-	if !sys.CheckProcessIdentity(output.Process.PID, output.Process.Command) {
+
+	startedAt := output.StartedAt
+	if status, ok := h.tunnelService.Status(output.Supervisor); output.Supervisor != 0 && ok {
+		// A supervised process waiting to be restarted is still up; it is only
+		// down once the supervisor gives up.
+		if status.Failed {
+			return framework.StatusDown, nil
+		}
+		if !status.Running {
+			return framework.StatusUp, nil
+		}
+		startedAt = status.StartedAt
+	} else if !sys.CheckProcessIdentity(output.Process.PID, output.Process.Command) {
 		return framework.StatusDown, nil
 	}
+
+	return h.checkConnections(ctx, output.TunnelName, startedAt)
+}
+
+// checkConnections asks Cloudflare whether the tunnel has active edge connections.
+// A process started within the grace period is assumed to be connecting.
+func (h *runHandler) checkConnections(ctx context.Context, tunnelName string, startedAt time.Time) (framework.Status, error) {
+	if time.Since(startedAt) < connectionGracePeriod {
+		return framework.StatusUp, nil
+	}
+
+	conns, err := h.tunnelService.Connections(ctx, &domain.Tunnel{Name: tunnelName})
+	if err != nil {
+		return framework.StatusUnknown, fmt.Errorf("failed to check tunnel connections: %w", err)
+	}
+	if conns == 0 {
+		return framework.StatusDegraded, ErrNoConnections
+	}
 	return framework.StatusUp, nil
 }
 
+// Describe summarizes the process by its PID, uptime, restarts, and metrics address.
+func (h *runHandler) Describe(snap framework.Snapshot[RunInput, RunOutput]) string {
+	output := snap.Output
+	if output.Process.PID == 0 {
+		return "no process (dry run)"
+	}
+
+	summary := fmt.Sprintf("pid %d", output.Process.PID)
+	if !output.StartedAt.IsZero() {
+		summary += fmt.Sprintf(", up %s", time.Since(output.StartedAt).Round(time.Second))
+	}
+	if output.Restarts > 0 {
+		summary += fmt.Sprintf(", restarts %d", output.Restarts)
+	}
+	if output.MetricsAddress != "" {
+		summary += fmt.Sprintf(", metrics %s", output.MetricsAddress)
+	}
+	return summary
+}
+
 func (h *runHandler) Recover(ctx context.Context, input RunInput) (RunOutput, framework.Status, error) {
 	return RunOutput{TunnelName: input.TunnelName}, framework.StatusDown, nil
 }
@@ -0,0 +1,107 @@
+package tunnel_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stupside/moley/v2/internal/domain"
+	tunnel "github.com/stupside/moley/v2/internal/features/tunnel/usecase"
+	framework "github.com/stupside/moley/v2/internal/platform/orchestration"
+)
+
+// fakeRunner supervises a single process with a fixed status and reports a
+// fixed number of edge connections.
+type fakeRunner struct {
+	status      tunnel.ProcessStatus
+	connections int
+	connErr     error
+	checked     bool // Connections was called
+}
+
+func (f *fakeRunner) Run(context.Context, *domain.Tunnel) (int, string, error) {
+	return f.status.PID, "", nil
+}
+func (f *fakeRunner) Stop(context.Context, int) (bool, error) { return true, nil }
+func (f *fakeRunner) Status(handle int) (tunnel.ProcessStatus, bool) {
+	return f.status, handle == f.status.PID
+}
+func (f *fakeRunner) Events() <-chan tunnel.ProcessEvent { return nil }
+func (f *fakeRunner) Connections(context.Context, *domain.Tunnel) (int, error) {
+	f.checked = true
+	return f.connections, f.connErr
+}
+
+func TestRunCheck(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      tunnel.ProcessStatus
+		connections int
+		connErr     error
+		want        framework.Status
+		wantErr     error
+		wantChecked bool
+	}{
+		{
+			name:   "connecting within the grace period",
+			status: tunnel.ProcessStatus{PID: 42, Running: true, StartedAt: time.Now().Add(-10 * time.Second)},
+			want:   framework.StatusUp,
+		},
+		{
+			name:        "connected after the grace period",
+			status:      tunnel.ProcessStatus{PID: 42, Running: true, StartedAt: time.Now().Add(-time.Minute)},
+			connections: 4,
+			want:        framework.StatusUp,
+			wantChecked: true,
+		},
+		{
+			name:        "no connections after the grace period",
+			status:      tunnel.ProcessStatus{PID: 42, Running: true, StartedAt: time.Now().Add(-time.Minute)},
+			want:        framework.StatusDegraded,
+			wantErr:     tunnel.ErrNoConnections,
+			wantChecked: true,
+		},
+		{
+			name:        "connections unavailable",
+			status:      tunnel.ProcessStatus{PID: 42, Running: true, StartedAt: time.Now().Add(-time.Minute)},
+			connErr:     errors.New("api down"),
+			want:        framework.StatusUnknown,
+			wantChecked: true,
+		},
+		{
+			name:   "waiting to be restarted",
+			status: tunnel.ProcessStatus{PID: 42, StartedAt: time.Now().Add(-time.Minute)},
+			want:   framework.StatusUp,
+		},
+		{
+			name:   "supervisor gave up",
+			status: tunnel.ProcessStatus{PID: 42, Failed: true},
+			want:   framework.StatusDown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{status: tt.status, connections: tt.connections, connErr: tt.connErr}
+			h := tunnel.NewRunHandler(runner)
+
+			output := tunnel.RunOutput{TunnelName: "test", Supervisor: 42}
+			output.Process.PID = 42
+
+			status, err := h.Check(context.Background(), output)
+			if status != tt.want {
+				t.Errorf("expected %s, got %s (%v)", tt.want, status, err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+			if tt.want == framework.StatusUp && err != nil {
+				t.Errorf("expected no error when up, got %v", err)
+			}
+			if runner.checked != tt.wantChecked {
+				t.Errorf("expected connections checked=%v, got %v", tt.wantChecked, runner.checked)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -11,6 +12,7 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/go-viper/mapstructure/v2"
 	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/file"
 	"github.com/knadh/koanf/providers/structs"
 	"github.com/knadh/koanf/v2"
 
@@ -22,6 +24,8 @@ const configTag = "yaml"
 type Manager[T any] struct {
 	k         *koanf.Koanf
 	path      string
+	defaults  *T
+	opts      []ConfigOption[T]
 	validator *validator.Validate
 }
 
@@ -31,6 +35,8 @@ func New[T any](path string, defaultConfig *T, opts ...ConfigOption[T]) (*Manage
 	m := &Manager[T]{
 		k:         koanf.New("."),
 		path:      path,
+		defaults:  defaultConfig,
+		opts:      opts,
 		validator: validator.New(),
 	}
 
@@ -77,10 +83,22 @@ func (m *Manager[T]) save() error {
 	return nil
 }
 
+// Validator is implemented by configurations with rules struct tags cannot
+// express, such as fields required by some values of another. Validate runs
+// after the struct tags passed.
+type Validator interface {
+	Validate() error
+}
+
 func (m *Manager[T]) validate(config *T) error {
 	if err := m.validator.Struct(config); err != nil {
 		return fmt.Errorf("validate config failed: %w", err)
 	}
+	if v, ok := any(config).(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return fmt.Errorf("validate config failed: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -89,7 +107,10 @@ func (m *Manager[T]) Get(validate bool) (*T, error) {
 	if err := m.k.UnmarshalWithConf("", config, koanf.UnmarshalConf{
 		Tag: configTag,
 		DecoderConfig: &mapstructure.DecoderConfig{
-			DecodeHook:       numericKeysToSliceHookFunc(),
+			DecodeHook: mapstructure.ComposeDecodeHookFunc(
+				numericKeysToSliceHookFunc(),
+				mapstructure.StringToTimeDurationHookFunc(),
+			),
 			WeaklyTypedInput: true,
 			Result:           config,
 			TagName:          configTag,
@@ -107,6 +128,55 @@ func (m *Manager[T]) Get(validate bool) (*T, error) {
 	return config, nil
 }
 
+// Reload rebuilds the configuration from the defaults and sources given to New.
+// Unlike New, a failing source is an error; the previous configuration is kept.
+func (m *Manager[T]) Reload() error {
+	prev := m.k
+
+	m.k = koanf.New(".")
+	if err := m.k.Load(structs.Provider(m.defaults, configTag), nil); err != nil {
+		m.k = prev
+		return fmt.Errorf("load default config failed: %w", err)
+	}
+
+	for _, opt := range m.opts {
+		if err := opt(m); err != nil {
+			m.k = prev
+			return fmt.Errorf("reload config failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Watch reloads the configuration whenever the file at the manager's path changes
+// and calls onChange with the validated result, or with the error that made it
+// invalid. It blocks until ctx is done.
+func (m *Manager[T]) Watch(ctx context.Context, onChange func(*T, error)) error {
+	path, err := filepath.Abs(m.path)
+	if err != nil {
+		return fmt.Errorf("resolve config path failed: %w", err)
+	}
+
+	provider := file.Provider(path)
+	if err := provider.Watch(func(_ any, err error) {
+		if err != nil {
+			onChange(nil, fmt.Errorf("watch config failed: %w", err))
+			return
+		}
+		if err := m.Reload(); err != nil {
+			onChange(nil, err)
+			return
+		}
+		onChange(m.Get(true))
+	}); err != nil {
+		return fmt.Errorf("watch config failed: %w", err)
+	}
+
+	<-ctx.Done()
+	return provider.Unwatch()
+}
+
 // Update updates configuration and saves it
 func (m *Manager[T]) Update(fn func(*T)) error {
 	config, err := m.Get(false)
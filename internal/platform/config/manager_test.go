@@ -1,8 +1,13 @@
 package config
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestNumericKeysToSliceHook_SequentialKeys(t *testing.T) {
@@ -118,3 +123,149 @@ func TestNumericKeysToSliceHook_NonSliceTarget(t *testing.T) {
 		t.Fatalf("expected map passthrough for non-slice target, got %T", result)
 	}
 }
+
+type durationConfig struct {
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+func TestGet_DecodesDurationStrings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("timeout: 1m30s\n"), 0600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	m, err := New(path, &durationConfig{}, WithSources[durationConfig](FileSource(path)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg, err := m.Get(true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Timeout != 90*time.Second {
+		t.Fatalf("expected timeout=1m30s, got %s", cfg.Timeout)
+	}
+}
+
+type crossFieldConfig struct {
+	Mode string `yaml:"mode" validate:"required"`
+	Port int    `yaml:"port"`
+}
+
+func (c *crossFieldConfig) Validate() error {
+	if c.Mode == "tcp" && c.Port == 0 {
+		return errors.New("mode tcp requires port")
+	}
+	return nil
+}
+
+func TestGet_RunsValidateMethod(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("mode: tcp\n"), 0600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	m, err := New(path, &crossFieldConfig{}, WithSources[crossFieldConfig](FileSource(path)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := m.Get(true); err == nil {
+		t.Fatal("expected Validate to reject the config")
+	}
+	if _, err := m.Get(false); err != nil {
+		t.Fatalf("expected no validation without validate, got %v", err)
+	}
+}
+
+type reloadConfig struct {
+	Name string `yaml:"name" validate:"required"`
+}
+
+func newReloadManager(t *testing.T, content string) (*Manager[reloadConfig], string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	m, err := New(path, &reloadConfig{}, WithSources[reloadConfig](FileSource(path)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return m, path
+}
+
+func TestReload_PicksUpFileChanges(t *testing.T) {
+	m, path := newReloadManager(t, "name: first\n")
+
+	if err := os.WriteFile(path, []byte("name: second\n"), 0600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if err := m.Reload(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg, err := m.Get(true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Name != "second" {
+		t.Fatalf("expected name=second after reload, got %s", cfg.Name)
+	}
+}
+
+func TestReload_KeepsPreviousOnBrokenFile(t *testing.T) {
+	m, path := newReloadManager(t, "name: first\n")
+
+	if err := os.WriteFile(path, []byte("name: [unterminated\n"), 0600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if err := m.Reload(); err == nil {
+		t.Fatal("expected reload of a broken file to fail")
+	}
+
+	cfg, err := m.Get(true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Name != "first" {
+		t.Fatalf("expected previous config to be kept, got name=%s", cfg.Name)
+	}
+}
+
+func TestWatch_NotifiesOnChange(t *testing.T) {
+	m, path := newReloadManager(t, "name: first\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan *reloadConfig, 8)
+	done := make(chan error, 1)
+	go func() {
+		done <- m.Watch(ctx, func(cfg *reloadConfig, err error) {
+			if err == nil {
+				changes <- cfg
+			}
+		})
+	}()
+
+	// The watcher may register after the first write, and a write can be seen
+	// half done, so keep writing until the new name comes through.
+	deadline := time.After(5 * time.Second)
+	retry := time.NewTicker(100 * time.Millisecond)
+	defer retry.Stop()
+	for seen := false; !seen; {
+		if err := os.WriteFile(path, []byte("name: second\n"), 0600); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+		select {
+		case cfg := <-changes:
+			seen = cfg.Name == "second"
+		case <-retry.C:
+		case <-deadline:
+			t.Fatal("timed out waiting for config change")
+		}
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
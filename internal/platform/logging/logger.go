@@ -67,6 +67,14 @@ func LogErrorf(err error, msg string, fields map[string]any) {
 	event.Msg(msg)
 }
 
+// Log writes msg at the given level. Unlike Fatal, a fatal level never exits,
+// which makes it safe for relaying another program's log records.
+func Log(level zerolog.Level, msg string, fields map[string]any) {
+	event := logger.WithLevel(level)
+	addFields(event, fields)
+	event.Msg(msg)
+}
+
 func Fatal(msg string) {
 	logger.Fatal().Msg(msg)
 }
@@ -0,0 +1,117 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func readLog(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func write(t *testing.T, f *RotatingFile, s string) {
+	t.Helper()
+	if _, err := f.Write([]byte(s)); err != nil {
+		t.Fatalf("Write(%q): %v", s, err)
+	}
+}
+
+func TestRotatingFileRotatesPastMaxSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "tunnel.log")
+	f, err := OpenRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	// Exactly reaching the limit does not rotate.
+	write(t, f, "abcde")
+	write(t, f, "fghij")
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Fatalf("expected no rotation at exactly the size limit, got %v", err)
+	}
+
+	// One more byte does, before it is written.
+	write(t, f, "k")
+	if got := readLog(t, path+".1"); got != "abcdefghij" {
+		t.Errorf("expected the full file to be rotated, got %q", got)
+	}
+	if got := readLog(t, path); got != "k" {
+		t.Errorf("expected the new file to hold the last write, got %q", got)
+	}
+
+	// Older files shift down and the oldest beyond the backups is dropped.
+	write(t, f, "0123456789")
+	write(t, f, "z")
+	if got := readLog(t, path+".2"); got != "k" {
+		t.Errorf("expected the older rotation in .2, got %q", got)
+	}
+	if got := readLog(t, path+".1"); got != "0123456789" {
+		t.Errorf("expected the newest rotation in .1, got %q", got)
+	}
+	write(t, f, "0123456789")
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected at most 2 backups, got %v", err)
+	}
+}
+
+func TestRotatingFileKeepsOversizedWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tunnel.log")
+	f, err := OpenRotatingFile(path, 4, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	// An empty file takes a write larger than the limit instead of rotating forever.
+	write(t, f, "oversized")
+	if got := readLog(t, path); got != "oversized" {
+		t.Errorf("expected the oversized write in the file, got %q", got)
+	}
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Errorf("expected no rotation of an empty file, got %v", err)
+	}
+}
+
+func TestRotatingFileWithoutBackupsTruncates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tunnel.log")
+	f, err := OpenRotatingFile(path, 4, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	write(t, f, "abcd")
+	write(t, f, "e")
+	if got := readLog(t, path); got != "e" {
+		t.Errorf("expected the file to start over, got %q", got)
+	}
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Errorf("expected no backup, got %v", err)
+	}
+}
+
+func TestRotatingFileContinuesExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tunnel.log")
+	if err := os.WriteFile(path, []byte("12345678"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := OpenRotatingFile(path, 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	// The size on disk counts toward the limit.
+	write(t, f, "abc")
+	if got := readLog(t, path+".1"); got != "12345678" {
+		t.Errorf("expected the existing content to be rotated, got %q", got)
+	}
+}
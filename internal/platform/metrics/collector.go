@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	logger "github.com/stupside/moley/v2/internal/platform/logging"
+)
+
+// scrapeTimeout bounds how long a scrape waits for one cloudflared.
+const scrapeTimeout = 5 * time.Second
+
+// Target is a tunnel to collect metrics for. Address is its cloudflared metrics
+// endpoint, empty when it has none; StatePath is its Moley counters file.
+type Target struct {
+	Tunnel    string
+	Address   string
+	StatePath string
+}
+
+// Collector serves the metrics of every target as one Prometheus exposition.
+// Targets are listed again on every scrape, so tunnels started later show up.
+type Collector struct {
+	targets func() ([]Target, error)
+	client  *http.Client
+}
+
+func NewCollector(targets func() ([]Target, error)) *Collector {
+	return &Collector{
+		targets: targets,
+		client:  &http.Client{Timeout: scrapeTimeout},
+	}
+}
+
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	targets, err := c.targets()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	scrapes := make([][]byte, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		if target.Address == "" {
+			continue
+		}
+		wg.Go(func() {
+			data, err := c.scrape(r.Context(), target.Address)
+			if err != nil {
+				logger.Debugf("Tunnel metrics unavailable", map[string]any{
+					"tunnel": target.Tunnel,
+					"error":  err.Error(),
+				})
+				return
+			}
+			scrapes[i] = data
+		})
+	}
+	wg.Wait()
+
+	exp := NewExposition()
+	for i, target := range targets {
+		up := 0.0
+		if scrapes[i] != nil {
+			up = 1
+		}
+		exp.Add("moley_tunnel_up", "Whether the tunnel's cloudflared answered the last scrape.", "gauge", up, "tunnel", target.Tunnel)
+
+		state, err := LoadState(target.StatePath)
+		if err != nil {
+			logger.Warnf("Failed to read tunnel counters", map[string]any{
+				"tunnel": target.Tunnel,
+				"error":  err.Error(),
+			})
+		} else {
+			exp.AddState(target.Tunnel, state)
+		}
+
+		if scrapes[i] != nil {
+			exp.AddScrape(target.Tunnel, scrapes[i])
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = exp.WriteTo(w)
+}
+
+func (c *Collector) scrape(ctx context.Context, addr string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+"/metrics", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,167 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// family is one metric family of the Prometheus text format.
+type family struct {
+	name    string
+	help    string
+	kind    string
+	samples []string
+}
+
+// Exposition merges metric families from several sources into one Prometheus
+// text exposition, writing each family's HELP and TYPE once.
+type Exposition struct {
+	order    []string
+	families map[string]*family
+}
+
+func NewExposition() *Exposition {
+	return &Exposition{families: make(map[string]*family)}
+}
+
+func (e *Exposition) family(name string) *family {
+	f, ok := e.families[name]
+	if !ok {
+		f = &family{name: name}
+		e.families[name] = f
+		e.order = append(e.order, name)
+	}
+	return f
+}
+
+// Add records a sample. labels are name/value pairs.
+func (e *Exposition) Add(name, help, kind string, value float64, labels ...string) {
+	e.addSample(name, name, help, kind, value, labels...)
+}
+
+// addSample records a sample of familyName named name, e.g. a summary's _sum.
+func (e *Exposition) addSample(familyName, name, help, kind string, value float64, labels ...string) {
+	f := e.family(familyName)
+	f.help, f.kind = help, kind
+
+	var b strings.Builder
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(&b, "%s=\"%s\"", labels[i], escapeLabel(labels[i+1]))
+		}
+		b.WriteByte('}')
+	}
+	fmt.Fprintf(&b, " %v", value)
+	f.samples = append(f.samples, b.String())
+}
+
+// AddState records a tunnel's operation counters.
+func (e *Exposition) AddState(tunnel string, state *State) {
+	const (
+		opsHelp      = "Resource operations performed by moley, by outcome."
+		durationHelp = "Time spent in resource operations, failed ones included."
+	)
+
+	for _, op := range state.Operations {
+		labels := []string{"tunnel", tunnel, "handler", op.Handler, "operation", op.Operation}
+		e.Add("moley_operations_total", opsHelp, "counter", float64(op.Successes), slices.Concat(labels, []string{"result", "success"})...)
+		e.Add("moley_operations_total", opsHelp, "counter", float64(op.Failures), slices.Concat(labels, []string{"result", "failure"})...)
+		e.addSample("moley_operation_duration_seconds", "moley_operation_duration_seconds_sum", durationHelp, "summary", op.Seconds, labels...)
+		e.addSample("moley_operation_duration_seconds", "moley_operation_duration_seconds_count", durationHelp, "summary", float64(op.Successes+op.Failures), labels...)
+	}
+}
+
+// AddScrape merges a Prometheus text exposition, adding a tunnel label to every sample.
+func (e *Exposition) AddScrape(tunnel string, data []byte) {
+	var current *family
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if rest, ok := strings.CutPrefix(line, "# HELP "); ok {
+			name, help, _ := strings.Cut(rest, " ")
+			current = e.family(name)
+			current.help = help
+			continue
+		}
+		if rest, ok := strings.CutPrefix(line, "# TYPE "); ok {
+			name, kind, _ := strings.Cut(rest, " ")
+			current = e.family(name)
+			current.kind = kind
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name := sampleName(line)
+		// Histogram and summary samples (_bucket, _sum, _count) follow their TYPE line.
+		if current == nil || !strings.HasPrefix(name, current.name) {
+			current = e.family(name)
+		}
+		current.samples = append(current.samples, withLabel(line, name, "tunnel", tunnel))
+	}
+}
+
+// WriteTo writes the merged exposition.
+func (e *Exposition) WriteTo(w io.Writer) (int64, error) {
+	var b bytes.Buffer
+	for _, name := range e.order {
+		f := e.families[name]
+		if len(f.samples) == 0 {
+			continue
+		}
+		if f.help != "" {
+			fmt.Fprintf(&b, "# HELP %s %s\n", f.name, f.help)
+		}
+		if f.kind != "" {
+			fmt.Fprintf(&b, "# TYPE %s %s\n", f.name, f.kind)
+		}
+		for _, sample := range f.samples {
+			b.WriteString(sample)
+			b.WriteByte('\n')
+		}
+	}
+	return b.WriteTo(w)
+}
+
+func sampleName(line string) string {
+	if i := strings.IndexAny(line, "{ "); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+// withLabel adds label=value to a sample line whose metric name is name.
+func withLabel(line, name, label, value string) string {
+	pair := fmt.Sprintf("%s=\"%s\"", label, escapeLabel(value))
+	rest := line[len(name):]
+
+	if labels, ok := strings.CutPrefix(rest, "{"); ok {
+		if strings.HasPrefix(labels, "}") {
+			return name + "{" + pair + labels
+		}
+		return name + "{" + pair + "," + labels
+	}
+	return name + "{" + pair + "}" + rest
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
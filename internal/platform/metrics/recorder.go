@@ -0,0 +1,131 @@
+// Package metrics records Moley's resource operations and merges them with
+// cloudflared's own metrics into a single Prometheus exposition.
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gofrs/flock"
+
+	logger "github.com/stupside/moley/v2/internal/platform/logging"
+	framework "github.com/stupside/moley/v2/internal/platform/orchestration"
+)
+
+// OperationStats accumulates the outcomes of one operation of one handler.
+type OperationStats struct {
+	Handler   string  `json:"handler"`
+	Operation string  `json:"operation"`
+	Successes int64   `json:"successes"`
+	Failures  int64   `json:"failures"`
+	Seconds   float64 `json:"seconds"` // total time spent, successes and failures
+}
+
+// State is the content of a tunnel's counters file.
+type State struct {
+	Operations []OperationStats `json:"operations"`
+}
+
+// FileRecorder persists operation counters to a JSON file, so they survive
+// restarts and can be read by `moley metrics` from another process.
+type FileRecorder struct {
+	path string
+}
+
+var _ framework.Recorder = (*FileRecorder)(nil)
+
+// NewFileRecorder returns a recorder that accumulates counters in path.
+func NewFileRecorder(path string) *FileRecorder {
+	return &FileRecorder{path: path}
+}
+
+// RecordOperation adds one operation to the counters file. Operations are rare
+// enough that every one of them is written through; failures are only logged.
+func (r *FileRecorder) RecordOperation(handler string, op framework.Operation, duration time.Duration, err error) {
+	if updateErr := r.update(handler, string(op), duration, err == nil); updateErr != nil {
+		logger.Debugf("Failed to record operation metrics", map[string]any{
+			"path":  r.path,
+			"error": updateErr.Error(),
+		})
+	}
+}
+
+func (r *FileRecorder) update(handler, op string, duration time.Duration, success bool) error {
+	// Several moley processes may record for the same tunnel (run, then stop).
+	fl := flock.New(r.path + ".lock")
+	if err := fl.Lock(); err != nil {
+		return fmt.Errorf("failed to lock counters file: %w", err)
+	}
+	defer func() { _ = fl.Unlock() }()
+
+	state, err := LoadState(r.path)
+	if err != nil {
+		return err
+	}
+
+	stats := state.find(handler, op)
+	if success {
+		stats.Successes++
+	} else {
+		stats.Failures++
+	}
+	stats.Seconds += duration.Seconds()
+
+	return state.save(r.path)
+}
+
+// LoadState reads a counters file. A missing file is an empty state.
+func LoadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read counters file: %w", err)
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse counters file: %w", err)
+	}
+	return &state, nil
+}
+
+func (s *State) find(handler, op string) *OperationStats {
+	for i := range s.Operations {
+		if s.Operations[i].Handler == handler && s.Operations[i].Operation == op {
+			return &s.Operations[i]
+		}
+	}
+	s.Operations = append(s.Operations, OperationStats{Handler: handler, Operation: op})
+	return &s.Operations[len(s.Operations)-1]
+}
+
+// save writes the state through a temporary file, so readers never see a partial file.
+func (s *State) save(path string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to marshal counters: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create counters file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write counters file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write counters file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace counters file: %w", err)
+	}
+	return nil
+}
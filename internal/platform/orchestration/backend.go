@@ -0,0 +1,230 @@
+package orchestration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gofrs/flock"
+	logger "github.com/stupside/moley/v2/internal/platform/logging"
+)
+
+// Backend stores the content of a lock file and guards it against concurrent
+// writers. The local file is the default; shared backends let several machines
+// operate the same resources.
+type Backend interface {
+	// Lock acquires the state lock, shared when readOnly, and returns the
+	// function that releases it. It fails with ErrLocked once ctx is done.
+	Lock(ctx context.Context, readOnly bool) (unlock func() error, err error)
+	// BreakLock clears a lock left behind by a dead holder, or any lock with
+	// force, and returns the holder it cleared; nil when none was recorded.
+	// A live holder is refused with ErrHolderAlive.
+	BreakLock(ctx context.Context, force bool) (*LockHolder, error)
+	// Read returns the stored state, or nil when nothing is stored yet.
+	Read() ([]byte, error)
+	// Write replaces the stored state. The caller holds an exclusive lock.
+	Write(data []byte) error
+	// String names the storage location in logs and errors.
+	String() string
+}
+
+// FileBackend stores state in a local file, flocked while it is in use and
+// rewritten in place, so every release locks the same inode. The previous
+// content is first copied to a .bak file, from which a crash mid-write can be
+// recovered; the exclusive holder is recorded in a .holder file next to it.
+type FileBackend struct {
+	path string
+}
+
+var _ Backend = (*FileBackend)(nil)
+
+func NewFileBackend(path string) *FileBackend {
+	return &FileBackend{path: path}
+}
+
+func (b *FileBackend) holderPath() string { return b.path + ".holder" }
+
+func (b *FileBackend) Lock(ctx context.Context, readOnly bool) (func() error, error) {
+	fl := flock.New(b.path)
+	if err := b.acquire(ctx, fl, readOnly); err != nil {
+		return nil, err
+	}
+	if readOnly {
+		return fl.Unlock, nil
+	}
+
+	if err := b.writeHolder(CurrentHolder()); err != nil {
+		logger.Debugf("Failed to record lock holder", map[string]any{"error": err.Error()})
+	}
+	return func() error {
+		_ = os.Remove(b.holderPath())
+		return fl.Unlock()
+	}, nil
+}
+
+// acquire takes fl, shared when readOnly, waiting for it until ctx is done.
+func (b *FileBackend) acquire(ctx context.Context, fl *flock.Flock, readOnly bool) error {
+	try, tryContext := fl.TryLock, fl.TryLockContext
+	if readOnly {
+		try, tryContext = fl.TryRLock, fl.TryRLockContext
+	}
+
+	locked, err := try()
+	if err != nil {
+		return fmt.Errorf("failed to acquire file lock: %w", err)
+	}
+	if locked {
+		return nil
+	}
+
+	fields := map[string]any{"path": b.path}
+	if holder := b.holder(); holder != nil {
+		fields["holder"] = holder.String()
+	}
+	logger.Infof("Waiting for the lock file", fields)
+
+	locked, err = tryContext(ctx, lockRetryDelay)
+	if !locked {
+		if ctx.Err() != nil {
+			return lockedError(b.path, b.holder())
+		}
+		return fmt.Errorf("failed to acquire file lock: %w", err)
+	}
+	return nil
+}
+
+// BreakLock clears a stale holder record. The flock of a dead process is
+// released by the kernel, so a lock that is still held has a live holder;
+// force only clears its record, as the flock itself cannot be broken.
+func (b *FileBackend) BreakLock(_ context.Context, force bool) (*LockHolder, error) {
+	holder := b.holder()
+
+	fl := flock.New(b.path)
+	locked, err := fl.TryLock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check file lock: %w", err)
+	}
+	if locked {
+		defer func() { _ = fl.Unlock() }()
+	} else if !force {
+		if holder == nil {
+			return nil, fmt.Errorf("%w: %s is held by another moley command", ErrHolderAlive, b.path)
+		}
+		return holder, fmt.Errorf("%w: %s is held by %s", ErrHolderAlive, b.path, holder)
+	}
+
+	if err := os.Remove(b.holderPath()); err != nil && !os.IsNotExist(err) {
+		return holder, fmt.Errorf("failed to remove lock holder: %w", err)
+	}
+	return holder, nil
+}
+
+// holder reads the recorded exclusive holder, nil when there is none.
+func (b *FileBackend) holder() *LockHolder {
+	data, err := os.ReadFile(b.holderPath())
+	if err != nil {
+		return nil
+	}
+	var holder LockHolder
+	if err := json.Unmarshal(data, &holder); err != nil {
+		return nil
+	}
+	return &holder
+}
+
+func (b *FileBackend) writeHolder(holder LockHolder) error {
+	data, err := json.Marshal(holder)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(b.holderPath(), data)
+}
+
+func (b *FileBackend) Read() ([]byte, error) {
+	data, err := os.ReadFile(b.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (b *FileBackend) Write(data []byte) error {
+	previous, err := os.ReadFile(b.path)
+	switch {
+	case err == nil && len(previous) > 0:
+		if err := writeFileAtomic(b.path+".bak", previous); err != nil {
+			return fmt.Errorf("failed to back up lock file: %w", err)
+		}
+	case err != nil && !os.IsNotExist(err):
+		return fmt.Errorf("failed to read lock file for backup: %w", err)
+	}
+
+	// Rewrite the file instead of renaming a new one over it: a command of an
+	// older release waiting on the flock holds the current inode and must find
+	// the new content there once it gets the lock.
+	f, err := os.OpenFile(b.path, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteAt(data, 0); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Truncate(int64(len(data))); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (b *FileBackend) String() string {
+	return b.path
+}
+
+// writeFileAtomic replaces path with data through a synced temporary file in
+// the same directory, so readers see either the old or the new content.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := writeTemp(path, data)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp) }()
+
+	return os.Rename(tmp, path)
+}
+
+// writeTemp writes data to a synced temporary file next to path and returns its name.
+func writeTemp(path string, data []byte) (name string, err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return "", err
+	}
+	if err = tmp.Chmod(0644); err != nil {
+		_ = tmp.Close()
+		return "", err
+	}
+	if err = tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return "", err
+	}
+	if err = tmp.Close(); err != nil {
+		return "", err
+	}
+	return tmp.Name(), nil
+}
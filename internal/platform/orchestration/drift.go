@@ -0,0 +1,97 @@
+package orchestration
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// DriftVersion is the schema version of the serialized DriftReport.
+const DriftVersion = 1
+
+// FieldDrift is a single input field whose live value differs from the recorded one.
+// Field is a dotted path into the input JSON (e.g. "access.session_duration").
+type FieldDrift struct {
+	Field    string `json:"field"`
+	Recorded any    `json:"recorded"`
+	Observed any    `json:"observed"`
+}
+
+// ResourceDrift lists the drifted fields of one tracked resource.
+// Missing is set when the resource was deleted outside of Moley.
+type ResourceDrift struct {
+	Handler string       `json:"handler"`
+	Key     string       `json:"key"`
+	Missing bool         `json:"missing,omitempty"`
+	Fields  []FieldDrift `json:"fields,omitempty"`
+}
+
+// DriftReport is the result of comparing live state with the lock file.
+// Checked counts the resources whose handler supports observation.
+type DriftReport struct {
+	Version   int             `json:"version"`
+	Checked   int             `json:"checked"`
+	Repaired  bool            `json:"repaired"`
+	Resources []ResourceDrift `json:"resources"`
+}
+
+// HasDrift reports whether any resource differs from its recorded state.
+func (r *DriftReport) HasDrift() bool {
+	return len(r.Resources) > 0
+}
+
+// diffFields compares recorded and observed by their JSON encoding and returns
+// every leaf that differs. Objects are compared key by key; arrays as a whole.
+func diffFields(recorded, observed any) ([]FieldDrift, error) {
+	a, err := toJSONValue(recorded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode recorded input: %w", err)
+	}
+	b, err := toJSONValue(observed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode observed input: %w", err)
+	}
+
+	var fields []FieldDrift
+	collectDrift("", a, b, &fields)
+	return fields, nil
+}
+
+func toJSONValue(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func collectDrift(path string, recorded, observed any, fields *[]FieldDrift) {
+	rm, rok := recorded.(map[string]any)
+	om, ook := observed.(map[string]any)
+	if rok && ook {
+		keys := maps.Clone(rm)
+		maps.Copy(keys, om)
+
+		for _, k := range slices.Sorted(maps.Keys(keys)) {
+			child := k
+			if path != "" {
+				child = path + "." + k
+			}
+			collectDrift(child, rm[k], om[k], fields)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(recorded, observed) {
+		if path == "" {
+			path = "."
+		}
+		*fields = append(*fields, FieldDrift{Field: path, Recorded: recorded, Observed: observed})
+	}
+}
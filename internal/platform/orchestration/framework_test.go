@@ -1,14 +1,26 @@
 package orchestration_test
 
 import (
+	"bufio"
+	"cmp"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/gofrs/flock"
 	framework "github.com/stupside/moley/v2/internal/platform/orchestration"
 )
 
@@ -110,7 +122,7 @@ func TestHashChangesOnDifferentInput(t *testing.T) {
 func TestLockFileSaveAndLoad(t *testing.T) {
 	chdir(t)
 
-	lf, err := framework.LoadLockFile()
+	lf, err := framework.LoadLockFile(framework.DefaultLockFilePath)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -128,7 +140,7 @@ func TestLockFileSaveAndLoad(t *testing.T) {
 	_ = lf.Close()
 
 	// Reload
-	lf2, err := framework.LoadLockFile()
+	lf2, err := framework.LoadLockFile(framework.DefaultLockFilePath)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -145,13 +157,27 @@ func TestLockFileSaveAndLoad(t *testing.T) {
 	}
 }
 
-func TestLockFileCorruptRecovery(t *testing.T) {
+func TestLockFileCorruptIsRefused(t *testing.T) {
 	chdir(t)
 	_ = os.WriteFile("moley.lock", []byte("not json{{{"), 0644)
 
-	lf, err := framework.LoadLockFile()
+	if _, err := framework.LoadLockFile(framework.DefaultLockFilePath); !errors.Is(err, framework.ErrCorruptLockFile) {
+		t.Fatalf("expected ErrCorruptLockFile, got %v", err)
+	}
+
+	// The refused load must release the file lock.
+	if _, err := framework.NewReconciler(); !errors.Is(err, framework.ErrCorruptLockFile) {
+		t.Fatalf("expected the reconciler to refuse a corrupt lock file, got %v", err)
+	}
+}
+
+func TestLockFileCorruptForceFresh(t *testing.T) {
+	chdir(t)
+	_ = os.WriteFile("moley.lock", []byte("not json{{{"), 0644)
+
+	lf, err := framework.OpenLockFile(framework.NewFileBackend(framework.DefaultLockFilePath), framework.LockFileOptions{ForceFresh: true})
 	if err != nil {
-		t.Fatalf("corrupt lock file should not error: %v", err)
+		t.Fatalf("force fresh should not error: %v", err)
 	}
 	defer func() { _ = lf.Close() }()
 
@@ -160,11 +186,80 @@ func TestLockFileCorruptRecovery(t *testing.T) {
 	}
 }
 
+func TestLockFileMigratesUnversionedFormat(t *testing.T) {
+	chdir(t)
+	legacy := `{"entries":[{"key":"a","handler_name":"node","input_hash":"h","data":{"output":{"pid":4294967296}}}]}`
+	_ = os.WriteFile("moley.lock", []byte(legacy), 0644)
+
+	lf, err := framework.LoadLockFile(framework.DefaultLockFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lf.Entries) != 1 || lf.Entries[0].Key != "a" {
+		t.Fatalf("expected the legacy entry to load, got %+v", lf.Entries)
+	}
+	if err := lf.Save(); err != nil {
+		t.Fatal(err)
+	}
+	_ = lf.Close()
+
+	var saved struct {
+		Version int `json:"version"`
+	}
+	data, _ := os.ReadFile("moley.lock")
+	if err := json.Unmarshal(data, &saved); err != nil || saved.Version != framework.LockFileVersion {
+		t.Errorf("expected version %d after save, got %d (%v)", framework.LockFileVersion, saved.Version, err)
+	}
+}
+
+func TestLockFileNewerVersionIsRefused(t *testing.T) {
+	chdir(t)
+	_ = os.WriteFile("moley.lock", []byte(fmt.Sprintf(`{"version":%d,"entries":[]}`, framework.LockFileVersion+1)), 0644)
+
+	if _, err := framework.LoadLockFile(framework.DefaultLockFilePath); !errors.Is(err, framework.ErrUnsupportedVersion) {
+		t.Fatalf("expected ErrUnsupportedVersion, got %v", err)
+	}
+}
+
+func TestLockFileSaveKeepsBackup(t *testing.T) {
+	chdir(t)
+
+	lf, err := framework.LoadLockFile(framework.DefaultLockFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lf.Close() }()
+
+	lf.Entries = []framework.LockEntry{{Key: "first", HandlerName: "node"}}
+	if err := lf.Save(); err != nil {
+		t.Fatal(err)
+	}
+	first, _ := os.ReadFile("moley.lock")
+
+	lf.Entries = []framework.LockEntry{{Key: "second", HandlerName: "node"}}
+	if err := lf.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	backup, err := os.ReadFile("moley.lock.bak")
+	if err != nil {
+		t.Fatalf("expected a backup after the second save: %v", err)
+	}
+	if string(backup) != string(first) {
+		t.Errorf("backup should hold the previous content, got %s", backup)
+	}
+
+	matches, _ := filepath.Glob("moley.lock.*.tmp")
+	if len(matches) != 0 {
+		t.Errorf("temporary files should not be left behind: %v", matches)
+	}
+}
+
 func TestLockFileEmptyRecovery(t *testing.T) {
 	chdir(t)
 	_ = os.WriteFile("moley.lock", []byte(""), 0644)
 
-	lf, err := framework.LoadLockFile()
+	lf, err := framework.LoadLockFile(framework.DefaultLockFilePath)
 	if err != nil {
 		t.Fatalf("empty lock file should not error: %v", err)
 	}
@@ -178,7 +273,7 @@ func TestLockFileEmptyRecovery(t *testing.T) {
 func TestLockFileMissingIsEmpty(t *testing.T) {
 	chdir(t)
 
-	lf, err := framework.LoadLockFile()
+	lf, err := framework.LoadLockFile(framework.DefaultLockFilePath)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -192,7 +287,7 @@ func TestLockFileMissingIsEmpty(t *testing.T) {
 func TestLockFilePurgeOrphans(t *testing.T) {
 	chdir(t)
 
-	lf, err := framework.LoadLockFile()
+	lf, err := framework.LoadLockFile(framework.DefaultLockFilePath)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -221,20 +316,266 @@ func TestLockFilePurgeOrphans(t *testing.T) {
 func TestLockFileSequentialLocking(t *testing.T) {
 	chdir(t)
 
-	lf1, err := framework.LoadLockFile()
+	lf1, err := framework.LoadLockFile(framework.DefaultLockFilePath)
 	if err != nil {
 		t.Fatal(err)
 	}
 	_ = lf1.Close()
 
 	// Second lock after release should succeed
-	lf2, err := framework.LoadLockFile()
+	lf2, err := framework.LoadLockFile(framework.DefaultLockFilePath)
 	if err != nil {
 		t.Fatalf("second lock after release should succeed: %v", err)
 	}
 	_ = lf2.Close()
 }
 
+func TestLockFilesAreSeparatedByPath(t *testing.T) {
+	chdir(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"staging", "prod"} {
+		r, err := framework.NewReconciler(framework.WithLockFile(name+".lock"), framework.WithIdentity(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		framework.Register(r, newTestHandler(name), staticResolver(name))
+		if err := r.Start(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"staging", "prod"} {
+		lf, err := framework.LoadLockFile(name + ".lock")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = lf.Close()
+
+		if lf.Identity != name || len(lf.Entries) != 1 || lf.Entries[0].HandlerName != name {
+			t.Errorf("%s.lock should only track %s, got identity %q and %+v", name, name, lf.Identity, lf.Entries)
+		}
+	}
+	if _, err := os.Stat(framework.DefaultLockFilePath); !os.IsNotExist(err) {
+		t.Errorf("default lock file should not be used, stat err = %v", err)
+	}
+}
+
+func TestLockFileIdentityMismatchIsRefused(t *testing.T) {
+	chdir(t)
+
+	r1, _ := framework.NewReconciler(framework.WithIdentity("moley-a"))
+	framework.Register(r1, newTestHandler("node"), staticResolver("a"))
+	if err := r1.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := framework.NewReconciler(framework.WithIdentity("moley-b"))
+	if !errors.Is(err, framework.ErrIdentityMismatch) {
+		t.Fatalf("expected ErrIdentityMismatch, got %v", err)
+	}
+
+	// The refused reconciler must not keep the file lock.
+	r3, err := framework.NewReconciler(framework.WithIdentity("moley-a"))
+	if err != nil {
+		t.Fatalf("matching identity should load: %v", err)
+	}
+	framework.Register(r3, newTestHandler("node"), staticResolver("a"))
+	if err := r3.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLockFileTimeoutNamesHolder(t *testing.T) {
+	chdir(t)
+
+	held, err := framework.LoadLockFile(framework.DefaultLockFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = held.Close() }()
+
+	backend := framework.NewFileBackend(framework.DefaultLockFilePath)
+	_, err = framework.OpenLockFile(backend, framework.LockFileOptions{Timeout: 50 * time.Millisecond})
+	if !errors.Is(err, framework.ErrLocked) {
+		t.Fatalf("expected ErrLocked, got %v", err)
+	}
+	if !strings.Contains(err.Error(), fmt.Sprintf("pid %d", os.Getpid())) {
+		t.Errorf("error should name the holder, got %v", err)
+	}
+
+	if _, err := backend.BreakLock(context.Background(), false); !errors.Is(err, framework.ErrHolderAlive) {
+		t.Errorf("breaking a held lock should be refused, got %v", err)
+	}
+}
+
+// Older releases flock the lock file itself; they must keep excluding newer
+// ones, also after the lock file is saved.
+func TestLockFileExcludesLegacyFlock(t *testing.T) {
+	chdir(t)
+
+	old := flock.New(framework.DefaultLockFilePath)
+	if err := old.Lock(); err != nil {
+		t.Fatal(err)
+	}
+
+	backend := framework.NewFileBackend(framework.DefaultLockFilePath)
+	if _, err := framework.OpenLockFile(backend, framework.LockFileOptions{Timeout: 50 * time.Millisecond}); !errors.Is(err, framework.ErrLocked) {
+		t.Fatalf("expected ErrLocked while an older release holds the lock, got %v", err)
+	}
+	if err := old.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	lf, err := framework.LoadLockFile(framework.DefaultLockFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lf.Entries = append(lf.Entries, framework.LockEntry{Key: "k", HandlerName: "h", Data: map[string]any{}})
+	if err := lf.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if locked, err := flock.New(framework.DefaultLockFilePath).TryLock(); err != nil || locked {
+		t.Fatalf("an older release should not lock the saved lock file, locked=%v err=%v", locked, err)
+	}
+
+	_ = lf.Close()
+
+	after := flock.New(framework.DefaultLockFilePath)
+	if locked, err := after.TryLock(); err != nil || !locked {
+		t.Fatalf("an older release should lock the released lock file, locked=%v err=%v", locked, err)
+	}
+	_ = after.Unlock()
+}
+
+// An older release blocked on the lock file during a save gets the lock only
+// once it is released, and finds the saved content in the file it waited on.
+func TestLockFileWriteKeepsLegacyWaiterExcluded(t *testing.T) {
+	chdir(t)
+	path, err := filepath.Abs(framework.DefaultLockFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lf, err := framework.LoadLockFile(framework.DefaultLockFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestLegacyReleaseHelper$")
+	cmd.Env = append(os.Environ(), "MOLEY_TEST_LEGACY_LOCK="+path)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = stdin.Close()
+		_ = cmd.Wait()
+	})
+
+	lines := make(chan string, 16)
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	if line := <-lines; line != "waiting" {
+		t.Fatalf("expected the older release to wait for the lock, got %q", line)
+	}
+	time.Sleep(100 * time.Millisecond) // let it block on the flock
+
+	for _, key := range []string{"first", "second"} {
+		lf.Entries = append(lf.Entries, framework.LockEntry{Key: key, HandlerName: "h", Data: map[string]any{}})
+		if err := lf.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case line := <-lines:
+		t.Fatalf("the older release got the lock while it was held: %q", line)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	if err := lf.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var line string
+	select {
+	case line = <-lines:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the older release did not get the released lock")
+	}
+	quoted, ok := strings.CutPrefix(line, "locked ")
+	if !ok {
+		t.Fatalf("expected the older release to report the lock, got %q", line)
+	}
+	content, err := strconv.Unquote(quoted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(content, `"second"`) {
+		t.Errorf("the older release should read the last save, got %s", content)
+	}
+
+	backend := framework.NewFileBackend(framework.DefaultLockFilePath)
+	if _, err := framework.OpenLockFile(backend, framework.LockFileOptions{Timeout: 50 * time.Millisecond}); !errors.Is(err, framework.ErrLocked) {
+		t.Errorf("expected ErrLocked while the older release holds the lock, got %v", err)
+	}
+}
+
+// TestLegacyReleaseHelper acts as a command of an older release: it blocks on
+// the flock of the lock file, reports what it reads, and holds the lock until
+// its stdin is closed.
+func TestLegacyReleaseHelper(t *testing.T) {
+	path := os.Getenv("MOLEY_TEST_LEGACY_LOCK")
+	if path == "" {
+		t.Skip("run by TestLockFileWriteKeepsLegacyWaiterExcluded")
+	}
+
+	fmt.Println("waiting")
+	fl := flock.New(path)
+	if err := fl.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Printf("locked %q\n", data)
+
+	_, _ = io.Copy(io.Discard, os.Stdin)
+	_ = fl.Unlock()
+}
+
+func TestLockFileBreakClearsStaleHolder(t *testing.T) {
+	chdir(t)
+
+	stale := framework.CurrentHolder()
+	stale.PID = 0
+	data, _ := json.Marshal(stale)
+	_ = os.WriteFile(framework.DefaultLockFilePath+".holder", data, 0644)
+
+	holder, err := framework.NewFileBackend(framework.DefaultLockFilePath).BreakLock(context.Background(), false)
+	if err != nil || holder == nil {
+		t.Fatalf("expected the stale holder to be cleared, got %v, %v", holder, err)
+	}
+	if _, err := os.Stat(framework.DefaultLockFilePath + ".holder"); !os.IsNotExist(err) {
+		t.Errorf("holder record should be removed, stat err = %v", err)
+	}
+}
+
 // --- Topological sort tests ---
 
 type orderTracker struct {
@@ -564,3 +905,1144 @@ func TestResourceAdded(t *testing.T) {
 		t.Error("resource 'a' should NOT have been recreated (unchanged)")
 	}
 }
+
+// --- Plan tests ---
+
+func TestPlanReportsChangesWithoutApplying(t *testing.T) {
+	chdir(t)
+	ctx := context.Background()
+	h := newTestHandler("handler")
+
+	r1, _ := framework.NewReconciler()
+	framework.Register(r1, h,
+		func(_ *framework.OutputRegistry) ([]testInput, error) {
+			return []testInput{{Name: "a", Value: 1}, {Name: "b"}}, nil
+		},
+	)
+	if err := r1.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	h.created = make(map[string]testOutput)
+	h.destroyed = make(map[string]bool)
+
+	r2, _ := framework.NewReconciler()
+	framework.Register(r2, h,
+		func(_ *framework.OutputRegistry) ([]testInput, error) {
+			return []testInput{{Name: "a", Value: 2}, {Name: "c"}}, nil
+		},
+	)
+	plan, err := r2.Plan(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(h.created) > 0 || len(h.destroyed) > 0 {
+		t.Error("plan should not create or destroy resources")
+	}
+
+	if len(plan.Nodes) != 1 {
+		t.Fatalf("expected 1 node plan, got %d", len(plan.Nodes))
+	}
+	got := make(map[string]framework.Action)
+	for _, c := range plan.Nodes[0].Changes {
+		got[c.Key] = c.Action
+	}
+	want := map[string]framework.Action{
+		"a": framework.ActionUpdate,
+		"b": framework.ActionRemove,
+		"c": framework.ActionAdd,
+	}
+	for key, action := range want {
+		if got[key] != action {
+			t.Errorf("key %q: expected %s, got %q", key, action, got[key])
+		}
+	}
+
+	// The lock file must still describe the first run.
+	lf, err := framework.LoadLockFile(framework.DefaultLockFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lf.Close() }()
+	if len(lf.Entries) != 2 {
+		t.Errorf("plan should not modify the lock file, got %d entries", len(lf.Entries))
+	}
+}
+
+func TestPlanMarksDownstreamPending(t *testing.T) {
+	chdir(t)
+
+	r, _ := framework.NewReconciler()
+	framework.Register(r, newTestHandler("upstream"), staticResolver("item"))
+	framework.Register(r, newTestHandler("downstream"),
+		func(reg *framework.OutputRegistry) ([]testInput, error) {
+			if _, ok := framework.GetOutput[testOutput](reg, "upstream", "item"); !ok {
+				return nil, fmt.Errorf("missing upstream output")
+			}
+			return []testInput{{Name: "derived"}}, nil
+		},
+		"upstream",
+	)
+
+	plan, err := r.Plan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(plan.Nodes) != 2 {
+		t.Fatalf("expected 2 node plans, got %d", len(plan.Nodes))
+	}
+	if plan.Nodes[0].Pending != "" || len(plan.Nodes[0].Changes) != 1 {
+		t.Errorf("upstream should plan one add, got %+v", plan.Nodes[0])
+	}
+	if plan.Nodes[1].Pending == "" {
+		t.Error("downstream should be pending until upstream exists")
+	}
+}
+
+func TestPlanJSONSchema(t *testing.T) {
+	chdir(t)
+	ctx := context.Background()
+	h := newTestHandler("handler")
+
+	r1, _ := framework.NewReconciler()
+	framework.Register(r1, h, staticResolver("item"))
+	if err := r1.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	r2, _ := framework.NewReconciler()
+	framework.Register(r2, h,
+		func(_ *framework.OutputRegistry) ([]testInput, error) {
+			return []testInput{{Name: "item", Value: 7}}, nil
+		},
+	)
+	plan, err := r2.Plan(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Version  int `json:"version"`
+		Handlers []struct {
+			Handler string `json:"handler"`
+			Changes []struct {
+				Key      string    `json:"key"`
+				Action   string    `json:"action"`
+				OldHash  string    `json:"old_hash"`
+				NewHash  string    `json:"new_hash"`
+				OldInput testInput `json:"old_input"`
+				NewInput testInput `json:"new_input"`
+			} `json:"changes"`
+		} `json:"handlers"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.Version != framework.PlanVersion {
+		t.Errorf("expected version %d, got %d", framework.PlanVersion, doc.Version)
+	}
+	if len(doc.Handlers) != 1 || len(doc.Handlers[0].Changes) != 1 {
+		t.Fatalf("expected a single change, got %s", data)
+	}
+	c := doc.Handlers[0].Changes[0]
+	if c.Action != "update" || c.Key != "item" {
+		t.Errorf("unexpected change: %+v", c)
+	}
+	if c.OldInput.Value != 0 || c.NewInput.Value != 7 {
+		t.Errorf("expected old/new inputs 0/7, got %d/%d", c.OldInput.Value, c.NewInput.Value)
+	}
+	if c.OldHash != hashJSON(testInput{Name: "item"}) || c.NewHash != hashJSON(testInput{Name: "item", Value: 7}) {
+		t.Error("old/new hashes should match the input hashes")
+	}
+}
+
+// --- Concurrency tests ---
+
+// inFlightTracker records the peak number of concurrent Create calls across handlers.
+type inFlightTracker struct {
+	mu      sync.Mutex
+	current int
+	peak    int
+}
+
+type slowHandler struct {
+	name    string
+	tracker *inFlightTracker
+}
+
+func (h *slowHandler) Name() string           { return h.name }
+func (h *slowHandler) Key(i testInput) string { return i.Name }
+
+func (h *slowHandler) Create(_ context.Context, input testInput) (testOutput, error) {
+	h.tracker.mu.Lock()
+	h.tracker.current++
+	h.tracker.peak = max(h.tracker.peak, h.tracker.current)
+	h.tracker.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	h.tracker.mu.Lock()
+	h.tracker.current--
+	h.tracker.mu.Unlock()
+
+	return testOutput{Name: input.Name, Created: true}, nil
+}
+
+func (h *slowHandler) Destroy(_ context.Context, _ testOutput) error { return nil }
+
+func (h *slowHandler) Check(_ context.Context, output testOutput) (framework.Status, error) {
+	if output.Created {
+		return framework.StatusUp, nil
+	}
+	return framework.StatusDown, nil
+}
+
+func (h *slowHandler) Recover(_ context.Context, _ testInput) (testOutput, framework.Status, error) {
+	return testOutput{}, framework.StatusDown, nil
+}
+
+func manyInputs(prefix string, n int) framework.InputResolver[testInput] {
+	return func(_ *framework.OutputRegistry) ([]testInput, error) {
+		inputs := make([]testInput, n)
+		for i := range inputs {
+			inputs[i] = testInput{Name: fmt.Sprintf("%s-%d", prefix, i)}
+		}
+		return inputs, nil
+	}
+}
+
+func TestConcurrencyIsBoundedAcrossNodes(t *testing.T) {
+	chdir(t)
+
+	tracker := &inFlightTracker{}
+	r, _ := framework.NewReconciler(framework.WithConcurrency(3))
+	framework.Register(r, &slowHandler{name: "left", tracker: tracker}, manyInputs("l", 4))
+	framework.Register(r, &slowHandler{name: "right", tracker: tracker}, manyInputs("r", 4))
+
+	if err := r.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if tracker.peak < 2 {
+		t.Errorf("independent resources should be created concurrently, peak was %d", tracker.peak)
+	}
+	if tracker.peak > 3 {
+		t.Errorf("concurrency limit of 3 exceeded, peak was %d", tracker.peak)
+	}
+}
+
+func TestLockFileOrderIsDeterministic(t *testing.T) {
+	chdir(t)
+
+	tracker := &inFlightTracker{}
+	r, _ := framework.NewReconciler(framework.WithConcurrency(8))
+	framework.Register(r, &slowHandler{name: "b-handler", tracker: tracker}, manyInputs("x", 5))
+	framework.Register(r, &slowHandler{name: "a-handler", tracker: tracker}, manyInputs("y", 5))
+
+	if err := r.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	lf, err := framework.LoadLockFile(framework.DefaultLockFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lf.Close() }()
+
+	sorted := slices.IsSortedFunc(lf.Entries, func(a, b framework.LockEntry) int {
+		return cmp.Or(cmp.Compare(a.HandlerName, b.HandlerName), cmp.Compare(a.Key, b.Key))
+	})
+	if len(lf.Entries) != 10 || !sorted {
+		t.Errorf("expected 10 entries sorted by handler and key, got %+v", lf.Entries)
+	}
+}
+
+func TestFailedDependencySkipsDependents(t *testing.T) {
+	chdir(t)
+
+	var order []string
+	r, _ := framework.NewReconciler()
+	framework.Register(r, &orderTracker{name: "root", order: &order},
+		func(_ *framework.OutputRegistry) ([]testInput, error) {
+			return nil, fmt.Errorf("boom")
+		},
+	)
+	framework.Register(r, &orderTracker{name: "child", order: &order}, staticResolver("a"), "root")
+	framework.Register(r, &orderTracker{name: "sibling", order: &order}, staticResolver("a"))
+
+	if err := r.Start(context.Background()); err == nil {
+		t.Fatal("expected an error from the failing root")
+	}
+
+	if slices.Contains(order, "child") {
+		t.Error("child should be skipped when its dependency fails")
+	}
+	if !slices.Contains(order, "sibling") {
+		t.Error("independent sibling should still be reconciled")
+	}
+}
+
+// --- Update strategy tests ---
+
+// updatingHandler updates in place unless the value becomes negative.
+type updatingHandler struct {
+	*testHandler
+	updated map[string]int
+}
+
+func (h *updatingHandler) Update(_ context.Context, current framework.Snapshot[testInput, testOutput], input testInput) (testOutput, error) {
+	if input.Value < 0 {
+		return testOutput{}, framework.ErrReplaceRequired
+	}
+	h.updated[input.Name] = input.Value
+	return current.Output, nil
+}
+
+// cbdHandler records the order of operations and can fail creates on demand.
+type cbdHandler struct {
+	ops        *[]string
+	failCreate bool
+}
+
+func (h *cbdHandler) Name() string              { return "cbd" }
+func (h *cbdHandler) Key(i testInput) string    { return i.Name }
+func (h *cbdHandler) CreateBeforeDestroy() bool { return true }
+
+func (h *cbdHandler) Create(_ context.Context, input testInput) (testOutput, error) {
+	if h.failCreate {
+		return testOutput{}, fmt.Errorf("create failed")
+	}
+	*h.ops = append(*h.ops, fmt.Sprintf("create:%d", input.Value))
+	return testOutput{Name: fmt.Sprintf("%s-%d", input.Name, input.Value), Created: true}, nil
+}
+
+func (h *cbdHandler) Destroy(_ context.Context, output testOutput) error {
+	*h.ops = append(*h.ops, "destroy:"+output.Name)
+	return nil
+}
+
+func (h *cbdHandler) Check(_ context.Context, output testOutput) (framework.Status, error) {
+	if output.Created {
+		return framework.StatusUp, nil
+	}
+	return framework.StatusDown, nil
+}
+
+func (h *cbdHandler) Recover(_ context.Context, _ testInput) (testOutput, framework.Status, error) {
+	return testOutput{}, framework.StatusDown, nil
+}
+
+func valueResolver(value int) framework.InputResolver[testInput] {
+	return func(_ *framework.OutputRegistry) ([]testInput, error) {
+		return []testInput{{Name: "item", Value: value}}, nil
+	}
+}
+
+func TestUpdaterAppliesInPlace(t *testing.T) {
+	chdir(t)
+	ctx := context.Background()
+	h := &updatingHandler{testHandler: newTestHandler("handler"), updated: make(map[string]int)}
+
+	r1, _ := framework.NewReconciler()
+	framework.Register(r1, h, valueResolver(1))
+	if err := r1.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	h.created = make(map[string]testOutput)
+
+	r2, _ := framework.NewReconciler()
+	framework.Register(r2, h, valueResolver(2))
+	if err := r2.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if h.updated["item"] != 2 {
+		t.Error("resource should have been updated in place")
+	}
+	if len(h.created) > 0 || len(h.destroyed) > 0 {
+		t.Error("in-place update should not create or destroy")
+	}
+
+	// The new input hash must be recorded so the next run is a no-op.
+	r3, _ := framework.NewReconciler()
+	framework.Register(r3, h, valueResolver(2))
+	plan, err := r3.Plan(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.HasChanges() {
+		t.Errorf("expected no changes after in-place update, got %+v", plan.Nodes)
+	}
+}
+
+func TestUpdaterFallsBackToReplace(t *testing.T) {
+	chdir(t)
+	ctx := context.Background()
+	h := &updatingHandler{testHandler: newTestHandler("handler"), updated: make(map[string]int)}
+
+	r1, _ := framework.NewReconciler()
+	framework.Register(r1, h, valueResolver(1))
+	if err := r1.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	h.created = make(map[string]testOutput)
+
+	r2, _ := framework.NewReconciler()
+	framework.Register(r2, h, valueResolver(-1))
+	if err := r2.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if !h.destroyed["item"] {
+		t.Error("old resource should be destroyed when replacement is required")
+	}
+	if _, ok := h.created["item"]; !ok {
+		t.Error("new resource should be created when replacement is required")
+	}
+}
+
+func TestCreateBeforeDestroyOrder(t *testing.T) {
+	chdir(t)
+	ctx := context.Background()
+	var ops []string
+	h := &cbdHandler{ops: &ops}
+
+	r1, _ := framework.NewReconciler()
+	framework.Register(r1, h, valueResolver(1))
+	if err := r1.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	ops = nil
+
+	r2, _ := framework.NewReconciler()
+	framework.Register(r2, h, valueResolver(2))
+	if err := r2.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"create:2", "destroy:item-1"}
+	if !slices.Equal(ops, want) {
+		t.Errorf("expected %v, got %v", want, ops)
+	}
+}
+
+func TestCreateBeforeDestroyKeepsOldOnFailure(t *testing.T) {
+	chdir(t)
+	ctx := context.Background()
+	var ops []string
+	h := &cbdHandler{ops: &ops}
+
+	r1, _ := framework.NewReconciler()
+	framework.Register(r1, h, valueResolver(1))
+	if err := r1.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	ops = nil
+	h.failCreate = true
+
+	r2, _ := framework.NewReconciler()
+	framework.Register(r2, h, valueResolver(2))
+	if err := r2.Start(ctx); err == nil {
+		t.Fatal("expected the failed create to surface")
+	}
+
+	if len(ops) != 0 {
+		t.Errorf("old resource should be untouched when the replacement fails, got %v", ops)
+	}
+
+	lf, err := framework.LoadLockFile(framework.DefaultLockFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lf.Close() }()
+	if len(lf.Entries) != 1 || lf.Entries[0].InputHash != hashJSON(testInput{Name: "item", Value: 1}) {
+		t.Errorf("lock file should still track the old resource, got %+v", lf.Entries)
+	}
+}
+
+// --- Drift tests ---
+
+// observingHandler reports live values from a map; missing names are gone.
+type observingHandler struct {
+	*updatingHandler
+	live map[string]int
+}
+
+func (h *observingHandler) Observe(_ context.Context, current framework.Snapshot[testInput, testOutput]) (testInput, error) {
+	value, ok := h.live[current.Input.Name]
+	if !ok {
+		return testInput{}, framework.ErrResourceMissing
+	}
+	return testInput{Name: current.Input.Name, Value: value}, nil
+}
+
+func newObservingHandler() *observingHandler {
+	return &observingHandler{
+		updatingHandler: &updatingHandler{testHandler: newTestHandler("handler"), updated: make(map[string]int)},
+		live:            make(map[string]int),
+	}
+}
+
+func TestDriftReportsChangedFields(t *testing.T) {
+	chdir(t)
+	ctx := context.Background()
+	h := newObservingHandler()
+
+	r1, _ := framework.NewReconciler()
+	framework.Register(r1, h, valueResolver(1))
+	if err := r1.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	h.live["item"] = 7
+
+	r2, _ := framework.NewReconciler()
+	framework.Register(r2, h, valueResolver(1))
+	report, err := r2.Drift(ctx, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Checked != 1 || len(report.Resources) != 1 {
+		t.Fatalf("expected one drifted resource out of one, got %+v", report)
+	}
+	fields := report.Resources[0].Fields
+	if len(fields) != 1 || fields[0].Field != "value" || fields[0].Recorded != float64(1) || fields[0].Observed != float64(7) {
+		t.Errorf("expected value drift 1 -> 7, got %+v", fields)
+	}
+
+	// Without repair the lock file is untouched, so a run still sees no change.
+	r3, _ := framework.NewReconciler()
+	framework.Register(r3, h, valueResolver(1))
+	plan, err := r3.Plan(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.HasChanges() {
+		t.Errorf("drift without repair should not change the plan, got %+v", plan.Nodes)
+	}
+}
+
+func TestDriftRepairRollsBackOnNextRun(t *testing.T) {
+	chdir(t)
+	ctx := context.Background()
+	h := newObservingHandler()
+
+	r1, _ := framework.NewReconciler()
+	framework.Register(r1, h, valueResolver(1))
+	if err := r1.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	h.live["item"] = 7
+
+	r2, _ := framework.NewReconciler()
+	framework.Register(r2, h, valueResolver(1))
+	report, err := r2.Drift(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Repaired {
+		t.Fatal("expected the report to be marked as repaired")
+	}
+
+	r3, _ := framework.NewReconciler()
+	framework.Register(r3, h, valueResolver(1))
+	if err := r3.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := h.updated["item"]; !ok || got != 1 {
+		t.Errorf("drifted resource should be updated back to 1, got %v (updated=%v)", got, ok)
+	}
+}
+
+func TestDriftReportsMissingResource(t *testing.T) {
+	chdir(t)
+	ctx := context.Background()
+	h := newObservingHandler()
+
+	r1, _ := framework.NewReconciler()
+	framework.Register(r1, h, valueResolver(1))
+	if err := r1.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	r2, _ := framework.NewReconciler()
+	framework.Register(r2, h, valueResolver(1))
+	report, err := r2.Drift(ctx, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Resources) != 1 || !report.Resources[0].Missing {
+		t.Errorf("expected the resource to be reported missing, got %+v", report.Resources)
+	}
+}
+
+// valueKeyedHandler keys resources by name and value, so drift in the value moves the entry.
+type valueKeyedHandler struct {
+	*observingHandler
+}
+
+func (h *valueKeyedHandler) Key(i testInput) string { return fmt.Sprintf("%s-%d", i.Name, i.Value) }
+
+func TestDriftRepairMovesEntryWhenKeyChanges(t *testing.T) {
+	chdir(t)
+	ctx := context.Background()
+	h := &valueKeyedHandler{observingHandler: newObservingHandler()}
+
+	r1, _ := framework.NewReconciler()
+	framework.Register(r1, h, valueResolver(1))
+	if err := r1.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	h.live["item"] = 7
+
+	r2, _ := framework.NewReconciler()
+	framework.Register(r2, h, valueResolver(1))
+	if _, err := r2.Drift(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+
+	lf, err := framework.LoadLockFile(framework.DefaultLockFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lf.Close() }()
+
+	var keys []string
+	for _, entry := range lf.Entries {
+		keys = append(keys, entry.Key)
+	}
+	if len(keys) != 1 || keys[0] != "item-7" {
+		t.Errorf("expected only the repaired entry item-7, got %v", keys)
+	}
+}
+
+// --- Refresh tests ---
+
+// refreshingHandler reports a new generation of the output on every refresh.
+type refreshingHandler struct {
+	*testHandler
+	generation int
+}
+
+func (h *refreshingHandler) Refresh(_ context.Context, output testOutput) (testOutput, error) {
+	h.generation++
+	output.Name = fmt.Sprintf("item-gen%d", h.generation)
+	return output, nil
+}
+
+func TestRefreshRecordsCurrentOutput(t *testing.T) {
+	chdir(t)
+	ctx := context.Background()
+	h := &refreshingHandler{testHandler: newTestHandler("handler")}
+
+	r1, _ := framework.NewReconciler()
+	framework.Register(r1, h, valueResolver(1))
+	if err := r1.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	r2, _ := framework.NewReconciler()
+	framework.Register(r2, h, valueResolver(1))
+	if err := r2.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(h.created) != 1 {
+		t.Errorf("refresh should not recreate the resource, got %d creates", len(h.created))
+	}
+
+	lf, err := framework.LoadLockFile(framework.DefaultLockFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lf.Close() }()
+
+	if len(lf.Entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(lf.Entries))
+	}
+	var snap framework.Snapshot[testInput, testOutput]
+	data, _ := json.Marshal(lf.Entries[0].Data)
+	if err := json.Unmarshal(data, &snap); err != nil {
+		t.Fatal(err)
+	}
+	if snap.Output.Name != "item-gen1" {
+		t.Errorf("expected refreshed output to be recorded, got %q", snap.Output.Name)
+	}
+	if lf.Entries[0].InputHash != hashJSON(testInput{Name: "item", Value: 1}) {
+		t.Error("refresh should keep the recorded input hash")
+	}
+}
+
+// --- Status tests ---
+
+// describingHandler summarizes its resources for status reports.
+type describingHandler struct {
+	*testHandler
+}
+
+func (h *describingHandler) Describe(snap framework.Snapshot[testInput, testOutput]) string {
+	return fmt.Sprintf("value %d", snap.Input.Value)
+}
+
+func TestStatusReportsTrackedResources(t *testing.T) {
+	chdir(t)
+	ctx := context.Background()
+	h := &describingHandler{testHandler: newTestHandler("handler")}
+
+	r1, _ := framework.NewReconciler()
+	framework.Register(r1, h, valueResolver(7))
+	if err := r1.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := os.ReadFile("moley.lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r2, err := framework.NewReconciler(framework.WithReadOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	framework.Register(r2, h, valueResolver(7))
+	report, err := r2.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Resources) != 1 {
+		t.Fatalf("expected 1 resource, got %d", len(report.Resources))
+	}
+	res := report.Resources[0]
+	if res.Status != framework.StatusUp || res.Details != "value 7" {
+		t.Errorf("unexpected status: %+v", res)
+	}
+	if report.Count(framework.StatusUp) != 1 {
+		t.Errorf("expected 1 resource up, got %d", report.Count(framework.StatusUp))
+	}
+
+	after, err := os.ReadFile("moley.lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(before) != string(after) {
+		t.Error("status must not modify the lock file")
+	}
+}
+
+func TestReadOnlyLockFileRefusesSave(t *testing.T) {
+	chdir(t)
+
+	lf, err := framework.LoadLockFileReadOnly(framework.DefaultLockFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lf.Close() }()
+
+	if err := lf.Save(); err == nil {
+		t.Error("expected saving a read-only lock file to fail")
+	}
+}
+
+// --- Degraded health tests ---
+
+var errNoTraffic = errors.New("no traffic")
+
+// degradingHandler creates a new generation of the output on every create and
+// reports the generations listed in degraded as degraded.
+type degradingHandler struct {
+	*testHandler
+	generation int
+	degraded   map[string]bool
+	reason     error
+	replace    bool
+}
+
+func newDegradingHandler(replace bool) *degradingHandler {
+	return &degradingHandler{testHandler: newTestHandler("degrading"), degraded: make(map[string]bool), reason: errNoTraffic, replace: replace}
+}
+
+func (h *degradingHandler) Create(ctx context.Context, input testInput) (testOutput, error) {
+	h.generation++
+	return h.testHandler.Create(ctx, testInput{Name: fmt.Sprintf("%s-gen%d", input.Name, h.generation)})
+}
+
+func (h *degradingHandler) Check(ctx context.Context, output testOutput) (framework.Status, error) {
+	if h.degraded[output.Name] {
+		return framework.StatusDegraded, h.reason
+	}
+	return h.testHandler.Check(ctx, output)
+}
+
+func (h *degradingHandler) ReplaceDegraded() bool { return h.replace }
+
+func lockEntry(t *testing.T, handler, key string) framework.LockEntry {
+	t.Helper()
+	lf, err := framework.LoadLockFile(framework.DefaultLockFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lf.Close() }()
+
+	for _, e := range lf.Entries {
+		if e.HandlerName == handler && e.Key == key {
+			return e
+		}
+	}
+	t.Fatalf("no lock entry for %s/%s", handler, key)
+	return framework.LockEntry{}
+}
+
+func TestDegradedResourceIsKeptAndRecorded(t *testing.T) {
+	chdir(t)
+	ctx := context.Background()
+	h := newDegradingHandler(false)
+
+	r1, _ := framework.NewReconciler()
+	framework.Register(r1, h, valueResolver(1))
+	if err := r1.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if health := lockEntry(t, "degrading", "item").Health; health == nil || health.Status != framework.StatusUp {
+		t.Fatalf("expected up health after create, got %+v", health)
+	}
+
+	h.degraded["item-gen1"] = true
+
+	r2, _ := framework.NewReconciler()
+	framework.Register(r2, h, valueResolver(1))
+	if err := r2.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(h.destroyed) != 0 || h.generation != 1 {
+		t.Errorf("degraded resource must be kept, destroyed=%v generation=%d", h.destroyed, h.generation)
+	}
+	health := lockEntry(t, "degrading", "item").Health
+	if health == nil || health.Status != framework.StatusDegraded || health.Reason != errNoTraffic.Error() || health.ObservedAt.IsZero() {
+		t.Errorf("expected degraded health with reason to be recorded, got %+v", health)
+	}
+}
+
+func TestDegradedResourceIsReplacedWhenHandlerOptsIn(t *testing.T) {
+	chdir(t)
+	ctx := context.Background()
+	h := newDegradingHandler(true)
+
+	r1, _ := framework.NewReconciler()
+	framework.Register(r1, h, valueResolver(1))
+	if err := r1.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	h.degraded["item-gen1"] = true
+
+	r2, _ := framework.NewReconciler()
+	framework.Register(r2, h, valueResolver(1))
+	plan, err := r2.Plan(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := plan.Nodes[0].Changes
+	if len(changes) != 1 || changes[0].Action != framework.ActionUpdate || changes[0].Reason == "" {
+		t.Fatalf("expected a replacement with a reason in the plan, got %+v", changes)
+	}
+
+	r3, _ := framework.NewReconciler()
+	framework.Register(r3, h, valueResolver(1))
+	if err := r3.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if !h.destroyed["item-gen1"] || h.generation != 2 {
+		t.Errorf("expected the degraded resource to be replaced, destroyed=%v generation=%d", h.destroyed, h.generation)
+	}
+	if health := lockEntry(t, "degrading", "item").Health; health == nil || health.Status != framework.StatusUp {
+		t.Errorf("expected the replacement to be recorded up, got %+v", health)
+	}
+}
+
+func TestDegradedReplacementsAreLimitedPerReason(t *testing.T) {
+	chdir(t)
+	ctx := context.Background()
+	h := newDegradingHandler(true)
+
+	run := func() {
+		t.Helper()
+		r, _ := framework.NewReconciler()
+		framework.Register(r, h, valueResolver(1))
+		if err := r.Start(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Every replacement comes up, then degrades again for the same reason.
+	degradeCurrent := func() { h.degraded[fmt.Sprintf("item-gen%d", h.generation)] = true }
+
+	run()
+	for range framework.MaxDegradedReplacements + 2 {
+		degradeCurrent()
+		run()
+	}
+	if want := 1 + framework.MaxDegradedReplacements; h.generation != want {
+		t.Errorf("expected %d generations, got %d", want, h.generation)
+	}
+	entry := lockEntry(t, "degrading", "item")
+	if entry.Health == nil || entry.Health.Status != framework.StatusDegraded || entry.Health.Reason != errNoTraffic.Error() {
+		t.Errorf("expected the resource to be kept degraded with its reason, got %+v", entry.Health)
+	}
+	if entry.Replaced == nil || entry.Replaced.Count != framework.MaxDegradedReplacements || entry.Replaced.Reason != errNoTraffic.Error() {
+		t.Errorf("expected the replacements to be recorded, got %+v", entry.Replaced)
+	}
+
+	// Another reason gets its own budget.
+	h.reason = errors.New("credentials revoked")
+	degradeCurrent()
+	run()
+	if want := 2 + framework.MaxDegradedReplacements; h.generation != want {
+		t.Errorf("expected a replacement for the new reason, got %d generations", h.generation)
+	}
+	if replaced := lockEntry(t, "degrading", "item").Replaced; replaced == nil || replaced.Count != 1 || replaced.Reason != h.reason.Error() {
+		t.Errorf("expected the count to restart for the new reason, got %+v", replaced)
+	}
+}
+
+// --- Recorder tests ---
+
+type recordedOperation struct {
+	handler string
+	op      framework.Operation
+	failed  bool
+}
+
+type testRecorder struct {
+	mu  sync.Mutex
+	ops []recordedOperation
+}
+
+func (r *testRecorder) RecordOperation(handler string, op framework.Operation, _ time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ops = append(r.ops, recordedOperation{handler: handler, op: op, failed: err != nil})
+}
+
+func TestRecorderReceivesOperations(t *testing.T) {
+	chdir(t)
+	ctx := context.Background()
+	h := newTestHandler("recorded")
+	rec := &testRecorder{}
+
+	r1, _ := framework.NewReconciler(framework.WithRecorder(rec))
+	framework.Register(r1, h, staticResolver("a"))
+	if err := r1.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	r2, _ := framework.NewReconciler(framework.WithRecorder(rec))
+	framework.Register(r2, h, staticResolver("a"))
+	if err := r2.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []recordedOperation{
+		{handler: "recorded", op: framework.OperationCreate},
+		{handler: "recorded", op: framework.OperationDestroy},
+	}
+	if !slices.Equal(rec.ops, expected) {
+		t.Errorf("expected %v, got %v", expected, rec.ops)
+	}
+}
+
+// --- State editing tests ---
+
+type importingHandler struct {
+	*testHandler
+	existing map[string]string // name -> provider ID of the resources that exist
+}
+
+func (h *importingHandler) Recover(_ context.Context, input testInput) (testOutput, framework.Status, error) {
+	if _, ok := h.existing[input.Name]; ok {
+		return testOutput{Name: input.Name, Created: true}, framework.StatusUp, nil
+	}
+	return testOutput{}, framework.StatusDown, nil
+}
+
+func (h *importingHandler) ID(_ context.Context, output testOutput) (string, error) {
+	return h.existing[output.Name], nil
+}
+
+func TestForgetKeepsResource(t *testing.T) {
+	chdir(t)
+	ctx := context.Background()
+	h := newTestHandler("node")
+
+	r1, _ := framework.NewReconciler()
+	framework.Register(r1, h, staticResolver("a"))
+	if err := r1.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	r2, _ := framework.NewReconciler()
+	framework.Register(r2, h, staticResolver("a"))
+	if _, err := r2.Forget("node", "missing"); !errors.Is(err, framework.ErrEntryNotFound) {
+		t.Fatalf("expected ErrEntryNotFound, got %v", err)
+	}
+
+	r3, _ := framework.NewReconciler()
+	framework.Register(r3, h, staticResolver("a"))
+	if _, err := r3.Forget("node", "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(h.destroyed) != 0 {
+		t.Errorf("forget must not destroy, destroyed=%v", h.destroyed)
+	}
+	r4, _ := framework.NewReconciler()
+	entries, err := r4.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected no entries after forget, got %+v", entries)
+	}
+}
+
+func TestImportAdoptsExistingResource(t *testing.T) {
+	chdir(t)
+	ctx := context.Background()
+	h := &importingHandler{testHandler: newTestHandler("node"), existing: map[string]string{"a": "id-7"}}
+
+	r1, _ := framework.NewReconciler()
+	framework.Register(r1, h, staticResolver("a"))
+	entry, err := r1.Import(ctx, "node", "a", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Health == nil || entry.Health.Status != framework.StatusUp {
+		t.Errorf("expected the imported entry to be recorded up, got %+v", entry.Health)
+	}
+
+	r2, _ := framework.NewReconciler()
+	framework.Register(r2, h, staticResolver("a"))
+	if _, err := r2.Import(ctx, "node", "a", ""); !errors.Is(err, framework.ErrAlreadyTracked) {
+		t.Fatalf("expected ErrAlreadyTracked, got %v", err)
+	}
+
+	// Once imported, a run adopts the resource instead of creating it.
+	r3, _ := framework.NewReconciler()
+	framework.Register(r3, h, staticResolver("a"))
+	if err := r3.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(h.created) != 0 {
+		t.Errorf("imported resource must not be created again, created=%v", h.created)
+	}
+}
+
+func TestImportByID(t *testing.T) {
+	chdir(t)
+	ctx := context.Background()
+	h := &importingHandler{testHandler: newTestHandler("node"), existing: map[string]string{"a": "id-7"}}
+
+	r1, _ := framework.NewReconciler()
+	framework.Register(r1, h, staticResolver("b"))
+	if _, err := r1.Import(ctx, "node", "b", "id-7"); !errors.Is(err, framework.ErrResourceMissing) {
+		t.Fatalf("expected ErrResourceMissing when nothing exists for the key, got %v", err)
+	}
+
+	// The ID of another resource must not bind the one found for the key.
+	r2, _ := framework.NewReconciler()
+	framework.Register(r2, h, staticResolver("a"))
+	if _, err := r2.Import(ctx, "node", "a", "id-8"); !errors.Is(err, framework.ErrIDMismatch) {
+		t.Fatalf("expected ErrIDMismatch for another ID, got %v", err)
+	}
+
+	r3, _ := framework.NewReconciler()
+	framework.Register(r3, h, staticResolver("a"))
+	entry, err := r3.Import(ctx, "node", "a", "id-7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snap framework.Snapshot[testInput, testOutput]
+	data, _ := json.Marshal(entry.Data)
+	_ = json.Unmarshal(data, &snap)
+	if snap.Output.Name != "a" {
+		t.Errorf("expected the output of the recovered resource, got %+v", snap.Output)
+	}
+
+	// Handlers without Identifier only import by key.
+	r4, _ := framework.NewReconciler()
+	framework.Register(r4, newTestHandler("plain"), staticResolver("b"))
+	if _, err := r4.Import(ctx, "plain", "b", "id-7"); err == nil {
+		t.Error("expected an error importing by ID without an Identifier")
+	}
+}
+
+// --- Ownership tests ---
+
+// foreignHandler finds an existing resource for every input, created by someone else.
+type foreignHandler struct {
+	*testHandler
+}
+
+func (h *foreignHandler) Recover(_ context.Context, input testInput) (testOutput, framework.Status, error) {
+	return testOutput{Name: input.Name, Created: true}, framework.StatusUp, framework.ErrNotOwned
+}
+
+func TestForeignResourceIsRefused(t *testing.T) {
+	chdir(t)
+	ctx := context.Background()
+	h := &foreignHandler{testHandler: newTestHandler("node")}
+
+	r1, _ := framework.NewReconciler()
+	framework.Register(r1, h, staticResolver("a"))
+	if err := r1.Start(ctx); !errors.Is(err, framework.ErrNotOwned) {
+		t.Fatalf("expected ErrNotOwned, got %v", err)
+	}
+	if len(h.created) != 0 {
+		t.Errorf("a foreign resource must not be created over, created=%v", h.created)
+	}
+
+	// Stop never destroys an untracked resource it does not own.
+	r2, _ := framework.NewReconciler()
+	framework.Register(r2, h, staticResolver("a"))
+	if err := r2.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(h.destroyed) != 0 {
+		t.Errorf("a foreign resource must not be destroyed, destroyed=%v", h.destroyed)
+	}
+}
+
+func TestAdoptTakesOverForeignResource(t *testing.T) {
+	chdir(t)
+	ctx := context.Background()
+	h := &foreignHandler{testHandler: newTestHandler("node")}
+
+	r1, _ := framework.NewReconciler(framework.WithAdopt())
+	framework.Register(r1, h, staticResolver("a"))
+	if err := r1.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(h.created) != 0 {
+		t.Errorf("an adopted resource must not be created, created=%v", h.created)
+	}
+
+	// Once adopted, the resource is tracked and destroyed like any other.
+	r2, _ := framework.NewReconciler()
+	framework.Register(r2, h, staticResolver("a"))
+	if err := r2.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !h.destroyed["a"] {
+		t.Error("expected the adopted resource to be destroyed on stop")
+	}
+}
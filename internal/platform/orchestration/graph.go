@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"sync"
 )
 
 // node is the type-erased interface used by the reconciler's DAG.
@@ -15,10 +16,16 @@ type node interface {
 	resolve(reg *OutputRegistry) error
 	reconcile(ctx context.Context, lf *LockFile) error
 	stop(ctx context.Context, lf *LockFile) error
+	plan(ctx context.Context, lf *LockFile) NodePlan
+	drift(ctx context.Context, lf *LockFile, repair bool) (int, []ResourceDrift, error)
+	status(ctx context.Context, lf *LockFile) []ResourceStatus
+	importResource(ctx context.Context, lf *LockFile, key, id string) error
 }
 
 // OutputRegistry holds outputs keyed by handler name + resource key.
+// It is safe for concurrent use by nodes reconciling in parallel.
 type OutputRegistry struct {
+	mu   sync.RWMutex
 	data map[string]any // key: "handlerName/resourceKey" → output value
 }
 
@@ -27,10 +34,14 @@ func newOutputRegistry() *OutputRegistry {
 }
 
 func (r *OutputRegistry) set(handlerName, key string, output any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.data[handlerName+"/"+key] = output
 }
 
 func (r *OutputRegistry) get(handlerName, key string) (any, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	v, ok := r.data[handlerName+"/"+key]
 	return v, ok
 }
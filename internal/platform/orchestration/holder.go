@@ -0,0 +1,66 @@
+package orchestration
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	sys "github.com/stupside/moley/v2/internal/platform/system"
+)
+
+const (
+	// DefaultLockTimeout bounds how long opening a lock file waits for the lock.
+	DefaultLockTimeout = 30 * time.Second
+	// lockRetryDelay is how often a held lock is retried until the timeout.
+	lockRetryDelay = 250 * time.Millisecond
+)
+
+// ErrLocked is returned when the state lock is still held after the timeout.
+var ErrLocked = errors.New("state is locked")
+
+// ErrHolderAlive is returned when breaking a lock whose holder is still running.
+var ErrHolderAlive = errors.New("lock holder is still running")
+
+// LockHolder records who holds a state lock, so a waiting command can name it
+// and a lock left behind by a dead process can be broken safely.
+type LockHolder struct {
+	PID       int       `json:"pid"`
+	Command   string    `json:"command"`
+	Host      string    `json:"host"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// CurrentHolder describes this process.
+func CurrentHolder() LockHolder {
+	host, _ := os.Hostname()
+	return LockHolder{
+		PID:       os.Getpid(),
+		Command:   strings.Join(os.Args, " "),
+		Host:      host,
+		StartedAt: time.Now().UTC(),
+	}
+}
+
+func (h LockHolder) String() string {
+	return fmt.Sprintf("pid %d (%s) on %s since %s", h.PID, h.Command, h.Host, h.StartedAt.Local().Format(time.RFC3339))
+}
+
+// Alive reports whether the holder may still be running. Processes on other
+// hosts cannot be checked, so they always count as alive.
+func (h LockHolder) Alive() bool {
+	host, err := os.Hostname()
+	if err != nil || host != h.Host {
+		return true
+	}
+	return sys.CheckProcessIdentity(h.PID, "")
+}
+
+// lockedError names the holder of a lock that could not be acquired.
+func lockedError(location string, holder *LockHolder) error {
+	if holder == nil {
+		return fmt.Errorf("%w: %s is held by another moley command", ErrLocked, location)
+	}
+	return fmt.Errorf("%w: %s is held by %s", ErrLocked, location, holder)
+}
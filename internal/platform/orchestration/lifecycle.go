@@ -1,17 +1,33 @@
 // Package orchestration provides DAG-based resource lifecycle management.
 package orchestration
 
-import "context"
+import (
+	"context"
+	"errors"
+	"time"
+)
 
-// Status represents the operational status of a resource (up, down, or unknown).
+// Status represents the operational status of a resource (up, degraded, down, or unknown).
 type Status string
 
 const (
-	StatusUp      Status = "up"
-	StatusDown    Status = "down"
-	StatusUnknown Status = "unknown"
+	StatusUp Status = "up"
+	// StatusDegraded is a resource that exists but does not work as intended,
+	// e.g. a tunnel process without edge connections. It is never dropped from
+	// the lock file; see DegradedReplacer.
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+	StatusUnknown  Status = "unknown"
 )
 
+// Health is the result of the last check of a resource, recorded in its lock
+// entry. Reason explains any status other than up.
+type Health struct {
+	Status     Status    `json:"status"`
+	Reason     string    `json:"reason,omitempty"`
+	ObservedAt time.Time `json:"observed_at"`
+}
+
 // Lifecycle defines how to create, destroy, and check a specific resource type.
 // TInput represents the full input (including upstream outputs).
 // TOutput represents the runtime output after resource creation.
@@ -25,8 +41,101 @@ type Lifecycle[TInput any, TOutput any] interface {
 	Create(ctx context.Context, input TInput) (TOutput, error)
 	// Destroy removes the resource using its output
 	Destroy(ctx context.Context, output TOutput) error
-	// Check verifies the resource status from its output
+	// Check verifies the resource status from its output. An error with
+	// StatusDegraded is the reason the resource is degraded; with any other
+	// status, an error means the status is unknown.
 	Check(ctx context.Context, output TOutput) (Status, error)
-	// Recover discovers a resource from its input when no lock entry exists
+	// Recover discovers a resource from its input when no lock entry exists.
+	// A resource that exists but was not created by this identity is reported
+	// as StatusUp with an error wrapping ErrNotOwned.
 	Recover(ctx context.Context, input TInput) (TOutput, Status, error)
 }
+
+// ErrNotOwned is returned by Lifecycle.Recover, along with StatusUp, when the
+// resource it found lacks the ownership marker written on create. Such a
+// resource is only taken over when the reconciler adopts (see WithAdopt).
+var ErrNotOwned = errors.New("resource is not owned by this tunnel")
+
+// ErrReplaceRequired is returned by Updater.Update when a change cannot be
+// applied in place. The reconciler then replaces the resource instead.
+var ErrReplaceRequired = errors.New("resource must be replaced")
+
+// Updater is an optional Lifecycle extension for handlers that can modify an
+// existing resource in place, keeping its identity. When a handler implements it,
+// input changes are applied with Update before any replacement is considered.
+type Updater[TInput any, TOutput any] interface {
+	// Update applies input to the resource described by current and returns its new output
+	Update(ctx context.Context, current Snapshot[TInput, TOutput], input TInput) (TOutput, error)
+}
+
+// CreateBeforeDestroyer is an optional Lifecycle extension for handlers whose
+// replacement can coexist with the resource it replaces. When CreateBeforeDestroy
+// returns true, a replacement is created and verified before the old resource is
+// destroyed, so a failed create leaves the old resource in place.
+type CreateBeforeDestroyer interface {
+	CreateBeforeDestroy() bool
+}
+
+// DegradedReplacer is an optional Lifecycle extension for handlers whose degraded
+// resources are fixed by replacing them, such as restarting a process. When
+// ReplaceDegraded returns true, Reconcile replaces a degraded resource even if its
+// input is unchanged. Otherwise degraded resources are kept as they are.
+//
+// A replacement that ends up degraded for the same reason did not help, so a
+// resource is replaced at most MaxDegradedReplacements times per reason. It is
+// then kept degraded with its reason until it has stayed up for
+// DegradedReplacementsResetAfter, or its input changes.
+type DegradedReplacer interface {
+	ReplaceDegraded() bool
+}
+
+const (
+	MaxDegradedReplacements        = 3
+	DegradedReplacementsResetAfter = 10 * time.Minute
+)
+
+// Replacements counts the replacements of a degraded resource for one reason,
+// recorded in its lock entry.
+type Replacements struct {
+	Reason string    `json:"reason"`
+	Count  int       `json:"count"`
+	LastAt time.Time `json:"last_at"`
+}
+
+// ErrResourceMissing is returned by Observer.Observe when the resource no longer exists.
+var ErrResourceMissing = errors.New("resource no longer exists")
+
+// Observer is an optional Lifecycle extension for handlers that can read the live
+// state of a resource. Observe returns the input describing the resource as it
+// exists now, so the framework can compare it field by field with the recorded
+// input. Fields the handler cannot observe, including those that make up the key,
+// must be copied from current.Input.
+type Observer[TInput any, TOutput any] interface {
+	// Observe reads the live state of the resource described by current
+	Observe(ctx context.Context, current Snapshot[TInput, TOutput]) (TInput, error)
+}
+
+// Refresher is an optional Lifecycle extension for handlers whose output changes
+// while the resource runs, such as a supervised process restarted under a new PID.
+// Refresh is called for every tracked resource found up, and the returned output
+// replaces the recorded one without affecting change detection.
+type Refresher[TOutput any] interface {
+	// Refresh returns the current output of a running resource
+	Refresh(ctx context.Context, output TOutput) (TOutput, error)
+}
+
+// Describer is an optional Lifecycle extension for handlers that can summarize a
+// tracked resource in one line for humans, such as a tunnel UUID or a process
+// PID and uptime. It must not call the provider.
+type Describer[TInput any, TOutput any] interface {
+	// Describe returns a short summary of the resource described by snapshot
+	Describe(snapshot Snapshot[TInput, TOutput]) string
+}
+
+// Identifier is an optional Lifecycle extension for handlers whose resources
+// have a provider ID. Importing with an ID checks it against the resource
+// Recover found, so a wrong ID never binds an unrelated resource.
+type Identifier[TOutput any] interface {
+	// ID returns the provider ID of the resource described by output
+	ID(ctx context.Context, output TOutput) (string, error)
+}
@@ -1,85 +1,196 @@
 package orchestration
 
 import (
+	"cmp"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
 	"slices"
+	"sync"
+	"time"
 
-	"github.com/gofrs/flock"
 	logger "github.com/stupside/moley/v2/internal/platform/logging"
 )
 
 const (
-	lockFilePath = "moley.lock"
+	// DefaultLockFilePath is used when no lock file path is configured.
+	DefaultLockFilePath = "moley.lock"
 )
 
+// ErrCorruptLockFile is returned when a lock file cannot be decoded. Starting
+// fresh would forget every tracked resource, so it is only done on request.
+var ErrCorruptLockFile = errors.New("lock file is corrupt")
+
+// ErrIdentityMismatch is returned when a lock file tracks resources of another
+// identity, e.g. a different tunnel, than the one being reconciled.
+var ErrIdentityMismatch = errors.New("lock file belongs to another identity")
+
 // LockEntry represents a persisted resource snapshot in moley.lock.
 type LockEntry struct {
-	Key         string `json:"key"`
-	Data        any    `json:"data"`
-	HandlerName string `json:"handler_name"`
-	InputHash   string `json:"input_hash,omitempty"`
+	Key         string  `json:"key"`
+	Data        any     `json:"data"`
+	HandlerName string  `json:"handler_name"`
+	InputHash   string  `json:"input_hash,omitempty"`
+	Health      *Health `json:"health,omitempty"`
+	// Replaced counts the replacements of the resource while degraded; see DegradedReplacer.
+	Replaced *Replacements `json:"replaced,omitempty"`
 }
 
 // LockFile manages persistent storage of resource snapshots in moley.lock.
+// Identity names what the entries belong to, so a lock file is never applied
+// to another tunnel. Entries may be mutated concurrently by independent nodes;
+// the reconciler goes through the unexported helpers, which serialize access with mu.
 type LockFile struct {
-	Entries []LockEntry `json:"entries"`
-	flock   *flock.Flock
+	Version  int         `json:"version"`
+	Identity string      `json:"identity,omitempty"`
+	Entries  []LockEntry `json:"entries"`
+	backend  Backend
+	unlock   func() error
+	mu       sync.Mutex
+	readOnly bool
+}
+
+// LockFileOptions control how OpenLockFile loads state.
+type LockFileOptions struct {
+	// ReadOnly takes a shared lock; Save always fails.
+	ReadOnly bool
+	// ForceFresh starts with no entries when the stored state is corrupt,
+	// instead of failing with ErrCorruptLockFile.
+	ForceFresh bool
+	// Timeout bounds the wait for the lock; zero means DefaultLockTimeout.
+	Timeout time.Duration
+}
+
+// LoadLockFile loads the lock file at path and acquires an exclusive file lock,
+// waiting up to DefaultLockTimeout. Returns an empty LockFile if the file is missing.
+func LoadLockFile(path string) (*LockFile, error) {
+	return OpenLockFile(NewFileBackend(path), LockFileOptions{})
+}
+
+// LoadLockFileReadOnly loads the lock file at path under a shared file lock, so
+// several readers can inspect it at once while writers wait. Save always fails.
+func LoadLockFileReadOnly(path string) (*LockFile, error) {
+	return OpenLockFile(NewFileBackend(path), LockFileOptions{ReadOnly: true})
 }
 
-// LoadLockFile loads moley.lock from disk and acquires an exclusive file lock.
-// Returns an empty LockFile if the file is missing or corrupt.
-func LoadLockFile() (*LockFile, error) {
-	fl := flock.New(lockFilePath)
+// OpenLockFile locks the state held by backend and loads it, migrating older
+// formats to LockFileVersion.
+func OpenLockFile(backend Backend, opts LockFileOptions) (*LockFile, error) {
+	timeout := cmp.Or(opts.Timeout, DefaultLockTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-	if err := fl.Lock(); err != nil {
-		return nil, fmt.Errorf("failed to acquire file lock: %w", err)
+	unlock, err := backend.Lock(ctx, opts.ReadOnly)
+	if err != nil {
+		return nil, err
 	}
 
-	data, err := os.ReadFile(lockFilePath)
+	lf, err := decodeLockFile(backend, opts)
+	if err != nil {
+		_ = unlock()
+		return nil, err
+	}
+	lf.unlock = unlock
+	return lf, nil
+}
+
+func decodeLockFile(backend Backend, opts LockFileOptions) (*LockFile, error) {
+	data, err := backend.Read()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return &LockFile{flock: fl}, nil
-		}
-		_ = fl.Unlock()
 		return nil, fmt.Errorf("failed to read lock file: %w", err)
 	}
 
+	fresh := &LockFile{Version: LockFileVersion, backend: backend, readOnly: opts.ReadOnly}
 	if len(data) == 0 {
-		return &LockFile{flock: fl}, nil
+		return fresh, nil
+	}
+
+	migrated, from, err := migrateLockFile(data)
+	if errors.Is(err, ErrUnsupportedVersion) {
+		return nil, fmt.Errorf("%s: %w", backend, err)
 	}
 
-	lf := &LockFile{flock: fl}
-	if err := json.Unmarshal(data, lf); err != nil {
+	lf := &LockFile{backend: backend, readOnly: opts.ReadOnly}
+	if err == nil {
+		err = json.Unmarshal(migrated, lf)
+	}
+	if err != nil {
+		if !opts.ForceFresh {
+			return nil, fmt.Errorf("%w: %s: %v (restore a backup or force a fresh start; resources will then be rediscovered)", ErrCorruptLockFile, backend, err)
+		}
 		logger.Warnf("Lock file is corrupt, starting fresh (resources will be rediscovered)", map[string]any{
+			"path":  backend.String(),
 			"error": err.Error(),
 		})
-		return &LockFile{flock: fl}, nil
+		return fresh, nil
 	}
 
+	if from != LockFileVersion {
+		logger.Infof("Migrated lock file", map[string]any{
+			"path": backend.String(),
+			"from": from,
+			"to":   LockFileVersion,
+		})
+	}
 	return lf, nil
 }
 
-// Close releases the file lock.
+// Claim binds the lock file to identity. A lock file written before identities
+// were recorded, or one that tracks nothing, is taken over; one that tracks
+// resources of another identity is refused with ErrIdentityMismatch.
+func (lf *LockFile) Claim(identity string) error {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	if lf.Identity == identity {
+		return nil
+	}
+	if lf.Identity != "" && len(lf.Entries) > 0 {
+		return fmt.Errorf("%w: %s tracks %q, not %q", ErrIdentityMismatch, lf.backend, lf.Identity, identity)
+	}
+
+	// A read-only lock file is never saved, so the claim only lives in memory.
+	lf.Identity = identity
+	return nil
+}
+
+// Close releases the state lock.
 func (lf *LockFile) Close() error {
-	if lf.flock == nil {
+	if lf.unlock == nil {
 		return nil
 	}
-	err := lf.flock.Unlock()
-	lf.flock = nil
+	err := lf.unlock()
+	lf.unlock = nil
 	return err
 }
 
-// Save persists the lock file to disk.
-// Safe to write directly — the exclusive flock prevents concurrent access.
+// Save persists the lock file to its backend.
+// Safe to write directly — the exclusive lock prevents concurrent access.
 func (lf *LockFile) Save() error {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	return lf.save()
+}
+
+// save writes the lock file; the caller must hold mu. Entries are sorted so
+// the file content does not depend on the order in which resources finished.
+func (lf *LockFile) save() error {
+	if lf.readOnly {
+		return errors.New("lock file was loaded read-only")
+	}
+
+	lf.Version = LockFileVersion
+	slices.SortStableFunc(lf.Entries, func(a, b LockEntry) int {
+		return cmp.Or(cmp.Compare(a.HandlerName, b.HandlerName), cmp.Compare(a.Key, b.Key))
+	})
+
 	data, err := json.Marshal(lf)
 	if err != nil {
 		return fmt.Errorf("failed to marshal lock file: %w", err)
 	}
 
-	if err := os.WriteFile(lockFilePath, data, 0644); err != nil {
+	if err := lf.backend.Write(data); err != nil {
 		return fmt.Errorf("failed to write lock file: %w", err)
 	}
 
@@ -88,6 +199,9 @@ func (lf *LockFile) Save() error {
 
 // PurgeOrphans removes lock entries whose handler name is not in the registered set.
 func (lf *LockFile) PurgeOrphans(registeredHandlers map[string]bool) error {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
 	before := len(lf.Entries)
 	lf.Entries = slices.DeleteFunc(lf.Entries, func(e LockEntry) bool {
 		return !registeredHandlers[e.HandlerName]
@@ -98,7 +212,51 @@ func (lf *LockFile) PurgeOrphans(registeredHandlers map[string]bool) error {
 		logger.Infof("Purged orphaned lock entries", map[string]any{
 			"purged": before - after,
 		})
-		return lf.Save()
+		return lf.save()
 	}
 	return nil
 }
+
+// snapshot returns a copy of the current entries.
+func (lf *LockFile) snapshot() []LockEntry {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	return slices.Clone(lf.Entries)
+}
+
+// find returns the entry with the given handler and key.
+func (lf *LockFile) find(handlerName, key string) (LockEntry, bool) {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	for _, e := range lf.Entries {
+		if e.Key == key && e.HandlerName == handlerName {
+			return e, true
+		}
+	}
+	return LockEntry{}, false
+}
+
+// upsert replaces the entry with the same handler and key, or appends it.
+func (lf *LockFile) upsert(entry LockEntry) {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	for i, e := range lf.Entries {
+		if e.Key == entry.Key && e.HandlerName == entry.HandlerName {
+			lf.Entries[i] = entry
+			return
+		}
+	}
+	lf.Entries = append(lf.Entries, entry)
+}
+
+// remove deletes the entry with the given handler and key, if any.
+func (lf *LockFile) remove(handlerName, key string) {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	lf.Entries = slices.DeleteFunc(lf.Entries, func(e LockEntry) bool {
+		return e.Key == key && e.HandlerName == handlerName
+	})
+}
@@ -1,10 +1,13 @@
 package orchestration
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	logger "github.com/stupside/moley/v2/internal/platform/logging"
 )
@@ -14,6 +17,9 @@ type typedNode[TInput any, TOutput any] struct {
 	handler  Lifecycle[TInput, TOutput]
 	resolver InputResolver[TInput]
 	deps     []string
+	limiter  limiter
+	recorder Recorder
+	adopt    bool
 	inputs   []TInput // resolved at reconcile time
 }
 
@@ -37,6 +43,9 @@ func (n *typedNode[TInput, TOutput]) resolve(reg *OutputRegistry) error {
 func (n *typedNode[TInput, TOutput]) newManager(lf *LockFile) *nodeManager[TInput, TOutput] {
 	return &nodeManager[TInput, TOutput]{
 		handler:  n.handler,
+		limiter:  n.limiter,
+		recorder: n.recorder,
+		adopt:    n.adopt,
 		lockFile: lf,
 	}
 }
@@ -49,9 +58,28 @@ func (n *typedNode[TInput, TOutput]) stop(ctx context.Context, lf *LockFile) err
 	return n.newManager(lf).Stop(ctx, n.inputs)
 }
 
+func (n *typedNode[TInput, TOutput]) plan(ctx context.Context, lf *LockFile) NodePlan {
+	return n.newManager(lf).Plan(ctx, n.inputs)
+}
+
+func (n *typedNode[TInput, TOutput]) drift(ctx context.Context, lf *LockFile, repair bool) (int, []ResourceDrift, error) {
+	return n.newManager(lf).Drift(ctx, repair)
+}
+
+func (n *typedNode[TInput, TOutput]) status(ctx context.Context, lf *LockFile) []ResourceStatus {
+	return n.newManager(lf).Status(ctx)
+}
+
+func (n *typedNode[TInput, TOutput]) importResource(ctx context.Context, lf *LockFile, key, id string) error {
+	return n.newManager(lf).Import(ctx, n.inputs, key, id)
+}
+
 // nodeManager manages resources of a specific type with full type safety.
 type nodeManager[TInput any, TOutput any] struct {
 	handler  Lifecycle[TInput, TOutput]
+	limiter  limiter
+	recorder Recorder
+	adopt    bool
 	lockFile *LockFile
 }
 
@@ -69,10 +97,29 @@ func unmarshalData[T any](data any, target *T) error {
 
 // Reconcile ensures the desired resources match the actual state.
 func (rm *nodeManager[TInput, TOutput]) Reconcile(ctx context.Context, desiredInputs []TInput) error {
-	currentRecords := rm.verifyRecords(ctx)
+	currentRecords, gone := rm.verifyRecords(ctx)
 
 	toRemove, toAdd, toUpdate := rm.computeActions(desiredInputs, currentRecords)
 
+	// A tracked resource that is gone but still desired is being healed.
+	for _, input := range toAdd {
+		if key := rm.handler.Key(input); gone[key] {
+			logger.Warnf("Healing resource: recreating after it disappeared", map[string]any{
+				"handler": rm.handler.Name(),
+				"key":     key,
+			})
+		}
+	}
+	for _, update := range toUpdate {
+		if update.old.replace {
+			logger.Warnf("Healing resource: replacing it while degraded", map[string]any{
+				"handler": rm.handler.Name(),
+				"key":     rm.handler.Key(update.newInput),
+				"reason":  update.old.health.Reason,
+			})
+		}
+	}
+
 	var errs []error
 
 	if err := rm.removeResources(ctx, toRemove); err != nil {
@@ -94,55 +141,356 @@ func (rm *nodeManager[TInput, TOutput]) Reconcile(ctx context.Context, desiredIn
 	return errors.Join(errs...)
 }
 
+// Plan computes the changes Reconcile would apply without creating or destroying anything.
+// Stale entries are dropped in memory only; the caller must not save the lock file.
+func (rm *nodeManager[TInput, TOutput]) Plan(ctx context.Context, desiredInputs []TInput) NodePlan {
+	currentRecords, _ := rm.verifyRecords(ctx)
+
+	toRemove, toAdd, toUpdate := rm.computeActions(desiredInputs, currentRecords)
+
+	handlerName := rm.handler.Name()
+	plan := NodePlan{Handler: handlerName, Changes: []Change{}}
+
+	for _, record := range toRemove {
+		plan.Changes = append(plan.Changes, Change{
+			Handler:  handlerName,
+			Key:      rm.handler.Key(record.snapshot.Input),
+			Action:   ActionRemove,
+			OldHash:  record.inputHash,
+			OldInput: record.snapshot.Input,
+		})
+	}
+
+	for _, input := range toAdd {
+		newHash, _ := computeHash(input)
+		plan.Changes = append(plan.Changes, Change{
+			Handler:  handlerName,
+			Key:      rm.handler.Key(input),
+			Action:   ActionAdd,
+			NewHash:  newHash,
+			NewInput: input,
+		})
+	}
+
+	for _, update := range toUpdate {
+		newHash, _ := computeHash(update.newInput)
+		change := Change{
+			Handler:  handlerName,
+			Key:      rm.handler.Key(update.newInput),
+			Action:   ActionUpdate,
+			OldHash:  update.old.inputHash,
+			NewHash:  newHash,
+			OldInput: update.old.snapshot.Input,
+			NewInput: update.newInput,
+		}
+		if update.old.replace {
+			change.Reason = fmt.Sprintf("replace degraded resource: %s", update.old.health.Reason)
+		}
+		plan.Changes = append(plan.Changes, change)
+	}
+
+	// computeActions walks maps, so sort for a stable, reviewable output.
+	slices.SortFunc(plan.Changes, func(a, b Change) int {
+		return cmp.Or(cmp.Compare(a.Key, b.Key), cmp.Compare(a.Action, b.Action))
+	})
+
+	return plan
+}
+
+// Drift compares the live state of every tracked resource with its recorded input
+// and returns the number of resources checked along with those that drifted.
+// Handlers that do not implement Observer are skipped.
+//
+// With repair, drifted entries are rewritten with the observed input so the next
+// Reconcile sees an input change and rolls the resource back to the desired state,
+// and entries of missing resources are dropped so they are recreated.
+// The caller is responsible for saving the lock file.
+func (rm *nodeManager[TInput, TOutput]) Drift(ctx context.Context, repair bool) (int, []ResourceDrift, error) {
+	observer, ok := rm.handler.(Observer[TInput, TOutput])
+	if !ok {
+		return 0, nil, nil
+	}
+
+	handlerName := rm.handler.Name()
+
+	type observedEntry struct {
+		entry    LockEntry
+		snap     Snapshot[TInput, TOutput]
+		observed TInput
+		drift    *ResourceDrift
+	}
+
+	var observations []*observedEntry
+	for _, entry := range rm.lockFile.snapshot() {
+		if entry.HandlerName == handlerName {
+			observations = append(observations, &observedEntry{entry: entry})
+		}
+	}
+
+	err := forEach(rm.limiter, observations, func(o *observedEntry) error {
+		if err := unmarshalData(o.entry.Data, &o.snap); err != nil {
+			return fmt.Errorf("failed to decode lock entry %s: %w", o.entry.Key, err)
+		}
+
+		observed, err := observer.Observe(ctx, o.snap)
+		if errors.Is(err, ErrResourceMissing) {
+			o.drift = &ResourceDrift{Handler: handlerName, Key: o.entry.Key, Missing: true}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to observe %s: %w", o.entry.Key, err)
+		}
+
+		fields, err := diffFields(o.snap.Input, observed)
+		if err != nil {
+			return fmt.Errorf("failed to compare %s: %w", o.entry.Key, err)
+		}
+		if len(fields) > 0 {
+			o.observed = observed
+			o.drift = &ResourceDrift{Handler: handlerName, Key: o.entry.Key, Fields: fields}
+		}
+		return nil
+	})
+
+	var drifts []ResourceDrift
+	for _, o := range observations {
+		if o.drift == nil {
+			continue
+		}
+
+		logger.Infof("Resource drifted", map[string]any{
+			"handler": handlerName,
+			"key":     o.entry.Key,
+			"missing": o.drift.Missing,
+			"fields":  len(o.drift.Fields),
+		})
+		drifts = append(drifts, *o.drift)
+
+		if !repair {
+			continue
+		}
+		if o.drift.Missing {
+			rm.lockFile.remove(handlerName, o.entry.Key)
+		} else {
+			// An observed field can be part of the key; the entry then moves.
+			if rm.handler.Key(o.observed) != o.entry.Key {
+				rm.lockFile.remove(handlerName, o.entry.Key)
+			}
+			rm.addToRegistry(Snapshot[TInput, TOutput]{Input: o.observed, Output: o.snap.Output}, o.entry.Health)
+		}
+	}
+
+	slices.SortFunc(drifts, func(a, b ResourceDrift) int {
+		return cmp.Compare(a.Key, b.Key)
+	})
+
+	return len(observations), drifts, err
+}
+
+// Status checks every tracked resource of this handler, leaving the lock file untouched.
+// A Check error is reported as StatusUnknown along with the error.
+func (rm *nodeManager[TInput, TOutput]) Status(ctx context.Context) []ResourceStatus {
+	handlerName := rm.handler.Name()
+	describer, canDescribe := rm.handler.(Describer[TInput, TOutput])
+
+	type checkedEntry struct {
+		entry  LockEntry
+		status ResourceStatus
+	}
+
+	var checks []*checkedEntry
+	for _, entry := range rm.lockFile.snapshot() {
+		if entry.HandlerName == handlerName {
+			checks = append(checks, &checkedEntry{
+				entry:  entry,
+				status: ResourceStatus{Handler: handlerName, Key: entry.Key, Status: StatusUnknown},
+			})
+		}
+	}
+
+	_ = forEach(rm.limiter, checks, func(c *checkedEntry) error {
+		var snap Snapshot[TInput, TOutput]
+		if err := unmarshalData(c.entry.Data, &snap); err != nil {
+			c.status.Reason = fmt.Sprintf("failed to decode lock entry: %s", err)
+			return nil
+		}
+		c.status.Input, c.status.Output = snap.Input, snap.Output
+
+		health := rm.checkHealth(ctx, snap.Output)
+		c.status.Status, c.status.Reason, c.status.ObservedAt = health.Status, health.Reason, health.ObservedAt
+		if canDescribe {
+			c.status.Details = describer.Describe(snap)
+		}
+		return nil
+	})
+
+	result := make([]ResourceStatus, 0, len(checks))
+	for _, c := range checks {
+		result = append(result, c.status)
+	}
+	slices.SortFunc(result, func(a, b ResourceStatus) int {
+		return cmp.Compare(a.Key, b.Key)
+	})
+	return result
+}
+
 type verifiedRecord[TInput any, TOutput any] struct {
 	snapshot  Snapshot[TInput, TOutput]
 	inputHash string
+	health    Health
+	// replace marks a degraded resource whose handler replaces it even when
+	// its input is unchanged.
+	replace bool
+	// replaced counts earlier replacements for the current degraded reason.
+	replaced *Replacements
 }
 
-// verifyRecords checks each lock entry against reality, removes stale ones.
-func (rm *nodeManager[TInput, TOutput]) verifyRecords(ctx context.Context) []verifiedRecord[TInput, TOutput] {
+// verifyRecords checks each lock entry against reality, removes stale ones, and
+// records the observed health in the remaining ones. It returns the remaining
+// records and the keys of the entries found stale.
+func (rm *nodeManager[TInput, TOutput]) verifyRecords(ctx context.Context) ([]verifiedRecord[TInput, TOutput], map[string]bool) {
 	handlerName := rm.handler.Name()
-	kept := make([]LockEntry, 0, len(rm.lockFile.Entries))
-	var records []verifiedRecord[TInput, TOutput]
 
-	for _, entry := range rm.lockFile.Entries {
-		if entry.HandlerName != handlerName {
-			kept = append(kept, entry)
-			continue
+	type checkedEntry struct {
+		entry    LockEntry
+		snap     Snapshot[TInput, TOutput]
+		health   Health
+		replaced *Replacements
+		valid    bool // entry decoded into a typed snapshot
+	}
+
+	refresher, canRefresh := rm.handler.(Refresher[TOutput])
+	replacer, canReplace := rm.handler.(DegradedReplacer)
+	replaceDegraded := canReplace && replacer.ReplaceDegraded()
+
+	var checks []*checkedEntry
+	for _, entry := range rm.lockFile.snapshot() {
+		if entry.HandlerName == handlerName {
+			checks = append(checks, &checkedEntry{entry: entry})
 		}
+	}
 
-		var snap Snapshot[TInput, TOutput]
-		if err := unmarshalData(entry.Data, &snap); err != nil {
+	_ = forEach(rm.limiter, checks, func(c *checkedEntry) error {
+		if err := unmarshalData(c.entry.Data, &c.snap); err != nil {
 			logger.Debugf("Failed to unmarshal entry during verify, keeping", map[string]any{
 				"handler": handlerName,
-				"key":     entry.Key,
+				"key":     c.entry.Key,
 				"error":   err.Error(),
 			})
-			kept = append(kept, entry)
-			continue
+			return nil
 		}
+		c.valid = true
 
-		status, err := rm.handler.Check(ctx, snap.Output)
+		// Down → stale entry, drop it. Up, degraded, or unknown → keep.
+		c.health = rm.checkHealth(ctx, c.snap.Output)
+		switch c.health.Status {
+		case StatusUnknown:
+			logger.Warnf("Unable to check resource, keeping it", map[string]any{
+				"handler": handlerName,
+				"key":     c.entry.Key,
+				"reason":  c.health.Reason,
+			})
+		case StatusDegraded:
+			logger.Warnf("Resource is degraded", map[string]any{
+				"handler": handlerName,
+				"key":     c.entry.Key,
+				"reason":  c.health.Reason,
+			})
+		}
+
+		if canRefresh && (c.health.Status == StatusUp || c.health.Status == StatusDegraded) {
+			output, err := refresher.Refresh(ctx, c.snap.Output)
+			if err != nil {
+				logger.Debugf("Failed to refresh output, keeping recorded one", map[string]any{
+					"handler": handlerName,
+					"key":     c.entry.Key,
+					"error":   err.Error(),
+				})
+				return nil
+			}
+			c.snap.Output = output
+		}
+		return nil
+	})
 
-		// StatusDown and no error → stale entry, drop it
-		if err == nil && status == StatusDown {
+	var records []verifiedRecord[TInput, TOutput]
+	gone := make(map[string]bool)
+	for _, c := range checks {
+		if !c.valid {
+			continue
+		}
+		if c.health.Status == StatusDown {
+			gone[c.entry.Key] = true
 			logger.Infof("Removing stale lock entry", map[string]any{
 				"handler": handlerName,
-				"key":     entry.Key,
+				"key":     c.entry.Key,
 			})
+			rm.lockFile.remove(handlerName, c.entry.Key)
 			continue
 		}
 
-		// StatusUp, Unknown, or error → keep conservatively
-		kept = append(kept, entry)
+		replaced := keptReplacements(c.entry.Replaced, c.health)
+		replace := replaceDegraded && c.health.Status == StatusDegraded
+		if replace && replaced != nil && replaced.Count >= MaxDegradedReplacements {
+			logger.Warnf("Resource is still degraded after replacing it, leaving it", map[string]any{
+				"handler":      handlerName,
+				"key":          c.entry.Key,
+				"reason":       c.health.Reason,
+				"replacements": replaced.Count,
+			})
+			replace = false
+		}
+
+		rm.lockFile.upsert(LockEntry{
+			Key:         c.entry.Key,
+			Data:        c.snap,
+			HandlerName: handlerName,
+			InputHash:   c.entry.InputHash,
+			Health:      &c.health,
+			Replaced:    replaced,
+		})
 		records = append(records, verifiedRecord[TInput, TOutput]{
-			snapshot:  snap,
-			inputHash: entry.InputHash,
+			snapshot:  c.snap,
+			inputHash: c.entry.InputHash,
+			health:    c.health,
+			replace:   replace,
+			replaced:  replaced,
 		})
 	}
 
-	rm.lockFile.Entries = kept
-	return records
+	return records, gone
+}
+
+// keptReplacements returns the replacement count that still applies to a
+// resource with the given health: it restarts when the resource is degraded for
+// another reason, and is dropped once the resource stayed up long enough.
+func keptReplacements(replaced *Replacements, health Health) *Replacements {
+	switch {
+	case replaced == nil:
+		return nil
+	case health.Status == StatusDegraded && health.Reason != replaced.Reason:
+		return nil
+	case health.Status == StatusUp && health.ObservedAt.Sub(replaced.LastAt) >= DegradedReplacementsResetAfter:
+		return nil
+	}
+	return replaced
+}
+
+// checkHealth checks a resource and turns the result into a Health. An error
+// explains a degraded resource; with any other status it makes it unknown.
+func (rm *nodeManager[TInput, TOutput]) checkHealth(ctx context.Context, output TOutput) Health {
+	status, err := rm.handler.Check(ctx, output)
+	health := Health{Status: status, ObservedAt: time.Now().UTC()}
+	if err != nil {
+		health.Reason = err.Error()
+		if status != StatusDegraded {
+			health.Status = StatusUnknown
+		}
+	}
+	if health.Status == "" {
+		health.Status = StatusUnknown
+	}
+	return health
 }
 
 // computeActions determines what resources need to be added, removed, or updated.
@@ -190,7 +538,7 @@ func (rm *nodeManager[TInput, TOutput]) computeActions(
 		}
 
 		if currentRecord, exists := currentMap[key]; exists {
-			if newHash != currentRecord.inputHash {
+			if newHash != currentRecord.inputHash || currentRecord.replace {
 				toUpdate = append(toUpdate, struct {
 					newInput TInput
 					old      verifiedRecord[TInput, TOutput]
@@ -205,8 +553,10 @@ func (rm *nodeManager[TInput, TOutput]) computeActions(
 }
 
 // createAndVerify creates a resource and verifies it's in StatusUp.
-func (rm *nodeManager[TInput, TOutput]) createAndVerify(ctx context.Context, input TInput) (TOutput, error) {
-	output, err := rm.handler.Create(ctx, input)
+func (rm *nodeManager[TInput, TOutput]) createAndVerify(ctx context.Context, input TInput) (output TOutput, err error) {
+	defer rm.record(OperationCreate, time.Now(), &err)
+
+	output, err = rm.handler.Create(ctx, input)
 	if err != nil {
 		return output, fmt.Errorf("failed to create resource: %w", err)
 	}
@@ -218,12 +568,26 @@ func (rm *nodeManager[TInput, TOutput]) createAndVerify(ctx context.Context, inp
 	return output, nil
 }
 
+// destroy destroys a resource and reports the operation.
+func (rm *nodeManager[TInput, TOutput]) destroy(ctx context.Context, output TOutput) (err error) {
+	defer rm.record(OperationDestroy, time.Now(), &err)
+	return rm.handler.Destroy(ctx, output)
+}
+
+// record reports an operation that started at start and ended with *err.
+func (rm *nodeManager[TInput, TOutput]) record(op Operation, start time.Time, err *error) {
+	rm.recorder.RecordOperation(rm.handler.Name(), op, time.Since(start), *err)
+}
+
 func (rm *nodeManager[TInput, TOutput]) errorIfNotUp(ctx context.Context, output TOutput) error {
 	status, err := rm.handler.Check(ctx, output)
-	if err != nil {
+	if err != nil && status != StatusDegraded {
 		return fmt.Errorf("failed to verify resource status: %w", err)
 	}
 	if status != StatusUp {
+		if err != nil {
+			return fmt.Errorf("resource not in up state (status: %s): %w", status, err)
+		}
 		return fmt.Errorf("resource not in up state (status: %s)", status)
 	}
 	return nil
@@ -231,28 +595,28 @@ func (rm *nodeManager[TInput, TOutput]) errorIfNotUp(ctx context.Context, output
 
 func (rm *nodeManager[TInput, TOutput]) removeResources(ctx context.Context, toRemove []verifiedRecord[TInput, TOutput]) error {
 	handlerName := rm.handler.Name()
-	var errs []error
-	for _, record := range toRemove {
+	return forEach(rm.limiter, toRemove, func(record verifiedRecord[TInput, TOutput]) error {
 		logger.Infof("Removing resource", map[string]any{
 			"handler": handlerName,
+			"key":     rm.handler.Key(record.snapshot.Input),
 		})
 
-		if err := rm.handler.Destroy(ctx, record.snapshot.Output); err != nil {
-			errs = append(errs, fmt.Errorf("failed to destroy resource: %w", err))
-			continue
+		if err := rm.destroy(ctx, record.snapshot.Output); err != nil {
+			return fmt.Errorf("failed to destroy resource: %w", err)
 		}
 
 		rm.removeFromRegistry(record.snapshot)
-	}
-	return errors.Join(errs...)
+		return nil
+	})
 }
 
 func (rm *nodeManager[TInput, TOutput]) addResources(ctx context.Context, toAdd []TInput) error {
 	handlerName := rm.handler.Name()
-	var errs []error
-	for _, input := range toAdd {
+	return forEach(rm.limiter, toAdd, func(input TInput) error {
+		key := rm.handler.Key(input)
 		logger.Infof("Adding resource", map[string]any{
 			"handler": handlerName,
+			"key":     key,
 		})
 
 		existingOutput, status, err := rm.handler.Recover(ctx, input)
@@ -260,27 +624,38 @@ func (rm *nodeManager[TInput, TOutput]) addResources(ctx context.Context, toAdd
 		var output TOutput
 
 		if status == StatusUp {
-			logger.Infof("Resource already exists, reusing", map[string]any{
-				"handler": handlerName,
-			})
+			if errors.Is(err, ErrNotOwned) {
+				if !rm.adopt {
+					return fmt.Errorf("%s/%s already exists: %w", handlerName, key, err)
+				}
+				logger.Warnf("Adopting existing resource", map[string]any{
+					"handler": handlerName,
+					"key":     key,
+				})
+			} else {
+				logger.Infof("Resource already exists, reusing", map[string]any{
+					"handler": handlerName,
+					"key":     key,
+				})
+			}
 			output = existingOutput
 		} else {
 			if status == StatusUnknown {
 				logger.Warnf("Unable to check if resource exists, attempting creation", map[string]any{
 					"handler": handlerName,
+					"key":     key,
 					"error":   err,
 				})
 			}
 
 			if output, err = rm.createAndVerify(ctx, input); err != nil {
-				errs = append(errs, err)
-				continue
+				return err
 			}
 		}
 
-		rm.addToRegistry(Snapshot[TInput, TOutput]{Input: input, Output: output})
-	}
-	return errors.Join(errs...)
+		rm.addToRegistry(Snapshot[TInput, TOutput]{Input: input, Output: output}, upNow())
+		return nil
+	})
 }
 
 func (rm *nodeManager[TInput, TOutput]) updateResources(
@@ -291,67 +666,137 @@ func (rm *nodeManager[TInput, TOutput]) updateResources(
 	},
 ) error {
 	handlerName := rm.handler.Name()
-	var errs []error
-	for _, update := range toUpdate {
+	return forEach(rm.limiter, toUpdate, func(update struct {
+		newInput TInput
+		old      verifiedRecord[TInput, TOutput]
+	}) error {
 		logger.Infof("Updating resource", map[string]any{
 			"handler": handlerName,
+			"key":     rm.handler.Key(update.newInput),
 		})
 
-		if err := rm.handler.Destroy(ctx, update.old.snapshot.Output); err != nil {
-			errs = append(errs, fmt.Errorf("failed to destroy old resource during update: %w", err))
-			continue
-		}
-
-		newOutput, err := rm.createAndVerify(ctx, update.newInput)
+		newOutput, err := rm.applyUpdate(ctx, update.old.snapshot, update.newInput, !update.old.replace)
 		if err != nil {
-			errs = append(errs, fmt.Errorf("failed to create updated resource: %w", err))
-			continue
+			return err
 		}
 
-		rm.removeFromRegistry(update.old.snapshot)
 		rm.addToRegistry(Snapshot[TInput, TOutput]{
 			Input:  update.newInput,
 			Output: newOutput,
+		}, upNow())
+
+		if update.old.replace {
+			replaced := &Replacements{Reason: update.old.health.Reason, LastAt: time.Now().UTC()}
+			if update.old.replaced != nil {
+				replaced.Count = update.old.replaced.Count
+			}
+			replaced.Count++
+			if entry, ok := rm.lockFile.find(handlerName, rm.handler.Key(update.newInput)); ok {
+				entry.Replaced = replaced
+				rm.lockFile.upsert(entry)
+			}
+		}
+		return nil
+	})
+}
+
+// applyUpdate rolls a resource forward to input using the safest strategy the
+// handler supports: in-place update, then create-before-destroy, then replace.
+// Without inPlace, the resource is always replaced.
+func (rm *nodeManager[TInput, TOutput]) applyUpdate(ctx context.Context, current Snapshot[TInput, TOutput], input TInput, inPlace bool) (TOutput, error) {
+	handlerName := rm.handler.Name()
+	key := rm.handler.Key(input)
+
+	if updater, ok := rm.handler.(Updater[TInput, TOutput]); ok && inPlace {
+		output, err := rm.updateInPlace(ctx, updater, current, input)
+		if !errors.Is(err, ErrReplaceRequired) {
+			return output, err
+		}
+		logger.Debugf("Resource cannot be updated in place, replacing", map[string]any{
+			"handler": handlerName,
+			"key":     key,
 		})
 	}
-	return errors.Join(errs...)
+
+	if cbd, ok := rm.handler.(CreateBeforeDestroyer); ok && cbd.CreateBeforeDestroy() {
+		output, err := rm.createAndVerify(ctx, input)
+		if err != nil {
+			return output, fmt.Errorf("failed to create replacement resource, keeping the old one: %w", err)
+		}
+
+		if err := rm.destroy(ctx, current.Output); err != nil {
+			// The replacement is live and must be tracked; only the old resource leaks.
+			logger.Warnf("Replacement created but old resource could not be destroyed", map[string]any{
+				"handler": handlerName,
+				"key":     key,
+				"error":   err.Error(),
+			})
+		}
+		return output, nil
+	}
+
+	if err := rm.destroy(ctx, current.Output); err != nil {
+		var zero TOutput
+		return zero, fmt.Errorf("failed to destroy old resource during update: %w", err)
+	}
+
+	output, err := rm.createAndVerify(ctx, input)
+	if err != nil {
+		// The old resource is gone; drop its entry so the next run recreates it.
+		rm.removeFromRegistry(current)
+		return output, fmt.Errorf("failed to create updated resource: %w", err)
+	}
+	return output, nil
+}
+
+// updateInPlace applies an in-place update and reports it, unless the handler
+// asks for a replacement instead.
+func (rm *nodeManager[TInput, TOutput]) updateInPlace(ctx context.Context, updater Updater[TInput, TOutput], current Snapshot[TInput, TOutput], input TInput) (output TOutput, err error) {
+	start := time.Now()
+	defer func() {
+		if !errors.Is(err, ErrReplaceRequired) {
+			rm.record(OperationUpdate, start, &err)
+		}
+	}()
+
+	output, err = updater.Update(ctx, current, input)
+	if errors.Is(err, ErrReplaceRequired) {
+		return output, err
+	}
+	if err != nil {
+		return output, fmt.Errorf("failed to update resource in place: %w", err)
+	}
+	if err := rm.errorIfNotUp(ctx, output); err != nil {
+		return output, fmt.Errorf("failed to verify updated resource: %w", err)
+	}
+	return output, nil
 }
 
 // addToRegistry and removeFromRegistry mutate in-memory only. Save() is called once at the end of Reconcile/Stop.
-func (rm *nodeManager[TInput, TOutput]) addToRegistry(snap Snapshot[TInput, TOutput]) {
+func (rm *nodeManager[TInput, TOutput]) addToRegistry(snap Snapshot[TInput, TOutput], health *Health) {
 	inputHash, _ := computeHash(snap.Input)
 
-	entry := LockEntry{
+	rm.lockFile.upsert(LockEntry{
 		Key:         rm.handler.Key(snap.Input),
 		Data:        snap,
 		HandlerName: rm.handler.Name(),
 		InputHash:   inputHash,
-	}
+		Health:      health,
+	})
+}
 
-	for i, e := range rm.lockFile.Entries {
-		if e.Key == entry.Key && e.HandlerName == entry.HandlerName {
-			rm.lockFile.Entries[i] = entry
-			return
-		}
-	}
-	rm.lockFile.Entries = append(rm.lockFile.Entries, entry)
+// upNow is the health of a resource that was just verified up.
+func upNow() *Health {
+	return &Health{Status: StatusUp, ObservedAt: time.Now().UTC()}
 }
 
 func (rm *nodeManager[TInput, TOutput]) removeFromRegistry(snap Snapshot[TInput, TOutput]) {
-	key := rm.handler.Key(snap.Input)
-	handlerName := rm.handler.Name()
-
-	for i, e := range rm.lockFile.Entries {
-		if e.Key == key && e.HandlerName == handlerName {
-			rm.lockFile.Entries = append(rm.lockFile.Entries[:i], rm.lockFile.Entries[i+1:]...)
-			return
-		}
-	}
+	rm.lockFile.remove(rm.handler.Name(), rm.handler.Key(snap.Input))
 }
 
 // Stop removes all resources managed by this handler (tracked + recovered).
 func (rm *nodeManager[TInput, TOutput]) Stop(ctx context.Context, inputs []TInput) error {
-	currentRecords := rm.verifyRecords(ctx)
+	currentRecords, _ := rm.verifyRecords(ctx)
 
 	trackedMap := make(map[string]verifiedRecord[TInput, TOutput])
 	for _, record := range currentRecords {
@@ -359,33 +804,53 @@ func (rm *nodeManager[TInput, TOutput]) Stop(ctx context.Context, inputs []TInpu
 		trackedMap[key] = record
 	}
 
-	allToRemove := currentRecords
+	type untrackedInput struct {
+		input  TInput
+		output TOutput
+		found  bool
+	}
 
-	handlerName := rm.handler.Name()
+	var untracked []*untrackedInput
 	for _, input := range inputs {
-		key := rm.handler.Key(input)
-		if _, exists := trackedMap[key]; exists {
-			continue
+		if _, exists := trackedMap[rm.handler.Key(input)]; !exists {
+			untracked = append(untracked, &untrackedInput{input: input})
 		}
+	}
 
-		output, status, err := rm.handler.Recover(ctx, input)
-		switch status {
-		case StatusUp:
-			logger.Infof("Found untracked running resource", map[string]any{
+	handlerName := rm.handler.Name()
+	_ = forEach(rm.limiter, untracked, func(u *untrackedInput) error {
+		output, status, err := rm.handler.Recover(ctx, u.input)
+		switch {
+		case status == StatusUp && errors.Is(err, ErrNotOwned) && !rm.adopt:
+			logger.Infof("Leaving untracked resource owned by someone else", map[string]any{
 				"handler": handlerName,
+				"key":     rm.handler.Key(u.input),
 			})
-			allToRemove = append(allToRemove, verifiedRecord[TInput, TOutput]{
-				snapshot: Snapshot[TInput, TOutput]{
-					Input:  input,
-					Output: output,
-				},
+		case status == StatusUp:
+			logger.Infof("Found untracked running resource", map[string]any{
+				"handler": handlerName,
+				"key":     rm.handler.Key(u.input),
 			})
-		case StatusUnknown:
+			u.output, u.found = output, true
+		case status == StatusUnknown:
 			logger.Warnf("Unable to determine untracked resource state, skipping", map[string]any{
 				"handler": handlerName,
 				"error":   err,
 			})
 		}
+		return nil
+	})
+
+	allToRemove := currentRecords
+	for _, u := range untracked {
+		if u.found {
+			allToRemove = append(allToRemove, verifiedRecord[TInput, TOutput]{
+				snapshot: Snapshot[TInput, TOutput]{
+					Input:  u.input,
+					Output: u.output,
+				},
+			})
+		}
 	}
 
 	logger.Debugf("Total resources to remove", map[string]any{
@@ -0,0 +1,67 @@
+package orchestration
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// LockFileVersion is the current lock file format. Files without a version
+// were written in format 1.
+const LockFileVersion = 2
+
+// ErrUnsupportedVersion is returned for a lock file written by a newer moley.
+var ErrUnsupportedVersion = errors.New("unsupported lock file version")
+
+// lockMigrations upgrades a decoded lock file in place by one format:
+// lockMigrations[v] turns format v into v+1. Add one whenever the shape of
+// LockFile, LockEntry, or a snapshot changes incompatibly.
+var lockMigrations = map[int]func(doc map[string]any) error{
+	// Format 2 records the tunnel identity and every entry's health. Both are
+	// optional, so format 1 documents are already valid.
+	1: func(map[string]any) error { return nil },
+}
+
+// migrateLockFile upgrades data to LockFileVersion and returns the version it
+// was written in. Numbers are kept as written so snapshots round-trip exactly.
+func migrateLockFile(data []byte) ([]byte, int, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, 0, err
+	}
+
+	from := 1
+	if raw, ok := doc["version"]; ok {
+		n, ok := raw.(json.Number)
+		if !ok {
+			return nil, 0, fmt.Errorf("version is %v, not a number", raw)
+		}
+		v, err := n.Int64()
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid version %s: %w", n, err)
+		}
+		from = int(v)
+	}
+
+	if from > LockFileVersion {
+		return nil, from, fmt.Errorf("%w %d: this moley reads up to version %d, upgrade it", ErrUnsupportedVersion, from, LockFileVersion)
+	}
+
+	for v := from; v < LockFileVersion; v++ {
+		migrate, ok := lockMigrations[v]
+		if !ok {
+			return nil, from, fmt.Errorf("%w %d: no migration to version %d", ErrUnsupportedVersion, v, v+1)
+		}
+		if err := migrate(doc); err != nil {
+			return nil, from, fmt.Errorf("failed to migrate lock file from version %d: %w", v, err)
+		}
+	}
+	doc["version"] = LockFileVersion
+
+	migrated, err := json.Marshal(doc)
+	return migrated, from, err
+}
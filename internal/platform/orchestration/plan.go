@@ -0,0 +1,67 @@
+package orchestration
+
+// PlanVersion is the schema version of the serialized Plan. Bump it whenever a
+// field is renamed or removed so consumers can detect incompatible output.
+const PlanVersion = 1
+
+// Action is the kind of change the reconciler would apply to a resource.
+type Action string
+
+const (
+	ActionAdd    Action = "add"
+	ActionUpdate Action = "update"
+	ActionRemove Action = "remove"
+)
+
+// Change describes a single resource mutation proposed by a plan.
+// OldInput is the input recorded in the lock file; NewInput is the desired input.
+type Change struct {
+	Handler  string `json:"handler"`
+	Key      string `json:"key"`
+	Action   Action `json:"action"`
+	OldHash  string `json:"old_hash,omitempty"`
+	NewHash  string `json:"new_hash,omitempty"`
+	OldInput any    `json:"old_input,omitempty"`
+	NewInput any    `json:"new_input,omitempty"`
+	// Reason explains an update whose input did not change, such as the
+	// replacement of a degraded resource.
+	Reason string `json:"reason,omitempty"`
+}
+
+// NodePlan lists the changes proposed for one handler.
+type NodePlan struct {
+	Handler string   `json:"handler"`
+	Changes []Change `json:"changes"`
+	// Pending explains why the handler could not be planned, typically because
+	// its inputs depend on upstream resources that do not exist yet.
+	Pending string `json:"pending,omitempty"`
+}
+
+// Plan is the full set of changes a reconciliation would apply, in dependency order.
+type Plan struct {
+	Version int        `json:"version"`
+	Nodes   []NodePlan `json:"handlers"`
+}
+
+// HasChanges reports whether applying the plan would mutate anything.
+func (p *Plan) HasChanges() bool {
+	for _, n := range p.Nodes {
+		if len(n.Changes) > 0 || n.Pending != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Count returns the number of changes planned for the given action.
+func (p *Plan) Count(action Action) int {
+	count := 0
+	for _, n := range p.Nodes {
+		for _, c := range n.Changes {
+			if c.Action == action {
+				count++
+			}
+		}
+	}
+	return count
+}
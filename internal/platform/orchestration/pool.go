@@ -0,0 +1,39 @@
+package orchestration
+
+import (
+	"errors"
+	"sync"
+)
+
+// limiter bounds the number of resource operations (Create, Destroy, Check,
+// Recover) in flight across all nodes of a reconciler.
+type limiter chan struct{}
+
+func newLimiter(size int) limiter {
+	return make(limiter, max(size, 1))
+}
+
+// do runs fn once a slot is available.
+func (l limiter) do(fn func()) {
+	l <- struct{}{}
+	defer func() { <-l }()
+	fn()
+}
+
+// forEach runs fn for every item through the limiter and waits for all of them.
+// Errors are joined in item order so the result does not depend on scheduling.
+func forEach[T any](l limiter, items []T, fn func(T) error) error {
+	errs := make([]error, len(items))
+
+	var wg sync.WaitGroup
+	for i, item := range items {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.do(func() { errs[i] = fn(item) })
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
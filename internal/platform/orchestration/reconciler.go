@@ -4,30 +4,129 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	logger "github.com/stupside/moley/v2/internal/platform/logging"
 )
 
 // Reconciler manages the lifecycle of multiple typed resources in dependency order.
 type Reconciler struct {
-	lockFile *LockFile
-	outputs  *OutputRegistry
-	nodes    []node
-	nodeMap  map[string]node
+	backend    Backend
+	identity   string
+	readOnly   bool
+	adopt      bool
+	forceFresh bool
+	lockWait   time.Duration
+	recorder   Recorder
+	lockFile   *LockFile
+	outputs    *OutputRegistry
+	limiter    limiter
+	nodes      []node
+	nodeMap    map[string]node
+}
+
+// ReconcilerOption configures a Reconciler.
+type ReconcilerOption func(*Reconciler)
+
+// WithConcurrency bounds the number of resource operations running at once.
+// Nodes whose dependencies are satisfied always reconcile concurrently; with a
+// limit of 1 their resource operations are still serialized.
+func WithConcurrency(limit int) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.limiter = newLimiter(limit)
+	}
+}
+
+// WithLockFile stores state in the lock file at path instead of DefaultLockFilePath.
+func WithLockFile(path string) ReconcilerOption {
+	return WithBackend(NewFileBackend(path))
+}
+
+// WithBackend stores state in backend instead of a local lock file.
+func WithBackend(backend Backend) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.backend = backend
+	}
+}
+
+// WithIdentity binds the lock file to identity, refusing one that tracks
+// resources of another identity (see LockFile.Claim).
+func WithIdentity(identity string) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.identity = identity
+	}
+}
+
+// WithReadOnly loads the lock file under a shared lock for commands that only
+// inspect state. Any attempt to save the lock file fails.
+func WithReadOnly() ReconcilerOption {
+	return func(r *Reconciler) {
+		r.readOnly = true
+	}
+}
+
+// WithAdopt takes over existing resources that Recover reports as ErrNotOwned
+// instead of refusing them. Start records them, and Stop destroys them.
+func WithAdopt() ReconcilerOption {
+	return func(r *Reconciler) {
+		r.adopt = true
+	}
+}
+
+// WithForceFresh starts from an empty lock file when the stored one is corrupt.
+// Tracked resources are forgotten and rediscovered by name where possible.
+func WithForceFresh() ReconcilerOption {
+	return func(r *Reconciler) {
+		r.forceFresh = true
+	}
+}
+
+// WithLockTimeout bounds how long NewReconciler waits for the lock file
+// (DefaultLockTimeout otherwise) before failing with ErrLocked.
+func WithLockTimeout(timeout time.Duration) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.lockWait = timeout
+	}
+}
+
+// WithRecorder reports every create, update, and destroy to rec.
+func WithRecorder(rec Recorder) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.recorder = rec
+	}
 }
 
 // NewReconciler creates a new reconciler backed by the lock file registry.
-func NewReconciler() (*Reconciler, error) {
-	lf, err := LoadLockFile()
+func NewReconciler(opts ...ReconcilerOption) (*Reconciler, error) {
+	r := &Reconciler{
+		backend:  NewFileBackend(DefaultLockFilePath),
+		outputs:  newOutputRegistry(),
+		limiter:  newLimiter(1),
+		recorder: nopRecorder{},
+		nodeMap:  make(map[string]node),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	lf, err := OpenLockFile(r.backend, LockFileOptions{
+		ReadOnly:   r.readOnly,
+		ForceFresh: r.forceFresh,
+		Timeout:    r.lockWait,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to load registry: %w", err)
 	}
+	if r.identity != "" {
+		if err := lf.Claim(r.identity); err != nil {
+			_ = lf.Close()
+			return nil, err
+		}
+	}
+	r.lockFile = lf
 
-	return &Reconciler{
-		lockFile: lf,
-		outputs:  newOutputRegistry(),
-		nodeMap:  make(map[string]node),
-	}, nil
+	return r, nil
 }
 
 // Register adds a typed resource with its input resolver and dependency list.
@@ -41,6 +140,9 @@ func Register[TInput any, TOutput any](
 		handler:  handler,
 		resolver: resolver,
 		deps:     deps,
+		limiter:  r.limiter,
+		recorder: r.recorder,
+		adopt:    r.adopt,
 	}
 	r.nodes = append(r.nodes, n)
 	r.nodeMap[handler.Name()] = n
@@ -70,7 +172,7 @@ func (r *Reconciler) Start(ctx context.Context) error {
 	// Load existing outputs from lock file into registry for recovery
 	r.loadOutputs("")
 
-	for _, n := range sorted {
+	err = r.walk(sorted, false, func(n node) error {
 		logger.Debugf("Reconciling", map[string]any{
 			"resource": n.name(),
 		})
@@ -86,6 +188,10 @@ func (r *Reconciler) Start(ctx context.Context) error {
 
 		// Publish this node's outputs for downstream consumers
 		r.loadOutputs(n.name())
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	logger.Info("Reconciliation completed")
@@ -116,11 +222,8 @@ func (r *Reconciler) Stop(ctx context.Context) error {
 		}
 	}
 
-	var errs []error
-
-	// Stop in reverse order
-	for i := len(sorted) - 1; i >= 0; i-- {
-		n := sorted[i]
+	// Stop in reverse order: a node is stopped once all of its dependents are.
+	err = r.walk(sorted, true, func(n node) error {
 		logger.Debugf("Stopping", map[string]any{
 			"resource": n.name(),
 		})
@@ -130,12 +233,106 @@ func (r *Reconciler) Stop(ctx context.Context) error {
 				"resource": n.name(),
 				"error":    err.Error(),
 			})
-			errs = append(errs, fmt.Errorf("stop failed: %s: %w", n.name(), err))
+			return fmt.Errorf("stop failed: %s: %w", n.name(), err)
 		}
-	}
+		return nil
+	})
 
 	logger.Info("Resources stopped")
-	return errors.Join(errs...)
+	return err
+}
+
+// Plan computes the changes Start would apply, in dependency order, without
+// creating or destroying any resource and without writing the lock file.
+func (r *Reconciler) Plan(ctx context.Context) (*Plan, error) {
+	defer func() { _ = r.lockFile.Close() }()
+
+	logger.Debug("Planning reconciliation")
+
+	sorted, err := r.topoSort()
+	if err != nil {
+		return nil, fmt.Errorf("dependency resolution failed: %w", err)
+	}
+
+	// Outputs only come from the lock file: nothing is applied while planning,
+	// so downstream handlers see exactly what already exists.
+	r.loadOutputs("")
+
+	plan := &Plan{Version: PlanVersion}
+	for _, n := range sorted {
+		logger.Debugf("Planning", map[string]any{
+			"resource": n.name(),
+		})
+
+		if err := n.resolve(r.outputs); err != nil {
+			plan.Nodes = append(plan.Nodes, NodePlan{
+				Handler: n.name(),
+				Changes: []Change{},
+				Pending: err.Error(),
+			})
+			continue
+		}
+
+		plan.Nodes = append(plan.Nodes, n.plan(ctx, r.lockFile))
+	}
+
+	return plan, nil
+}
+
+// Drift compares the live state of every tracked resource with the lock file.
+// With repair, the lock file is updated so the next Start rolls drifted
+// resources back to the desired state; nothing is changed on the provider.
+func (r *Reconciler) Drift(ctx context.Context, repair bool) (*DriftReport, error) {
+	defer func() { _ = r.lockFile.Close() }()
+
+	logger.Debug("Detecting drift")
+
+	sorted, err := r.topoSort()
+	if err != nil {
+		return nil, fmt.Errorf("dependency resolution failed: %w", err)
+	}
+
+	report := &DriftReport{Version: DriftVersion, Resources: []ResourceDrift{}}
+
+	var errs []error
+	for _, n := range sorted {
+		checked, drifts, err := n.drift(ctx, r.lockFile, repair)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("drift detection failed: %s: %w", n.name(), err))
+		}
+		report.Checked += checked
+		report.Resources = append(report.Resources, drifts...)
+	}
+
+	if repair && report.HasDrift() {
+		if err := r.lockFile.Save(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to save lock file: %w", err))
+		} else {
+			report.Repaired = true
+		}
+	}
+
+	return report, errors.Join(errs...)
+}
+
+// Status checks every resource tracked in the lock file and reports whether it
+// is up, in dependency order. Nothing is created, destroyed, or saved.
+func (r *Reconciler) Status(ctx context.Context) (*StatusReport, error) {
+	defer func() { _ = r.lockFile.Close() }()
+
+	logger.Debug("Checking resource status")
+
+	sorted, err := r.topoSort()
+	if err != nil {
+		return nil, fmt.Errorf("dependency resolution failed: %w", err)
+	}
+
+	report := &StatusReport{Version: StatusVersion, Resources: []ResourceStatus{}}
+	for _, n := range sorted {
+		report.Resources = append(report.Resources, n.status(ctx, r.lockFile)...)
+	}
+
+	return report, nil
 }
 
 // topoSort returns nodes in dependency order using Kahn's algorithm.
@@ -185,6 +382,68 @@ func (r *Reconciler) topoSort() ([]node, error) {
 	return sorted, nil
 }
 
+// walk runs fn for every node as soon as the nodes it waits on have finished,
+// so independent branches of the DAG run concurrently. Forward walks wait on
+// dependencies and skip nodes whose dependencies failed; reverse walks wait on
+// dependents and always run, so cleanup continues past failures.
+func (r *Reconciler) walk(sorted []node, reverse bool, fn func(node) error) error {
+	waitsOn := make(map[string][]string, len(sorted))
+	for _, n := range sorted {
+		for _, dep := range n.dependencies() {
+			if reverse {
+				waitsOn[dep] = append(waitsOn[dep], n.name())
+			} else {
+				waitsOn[n.name()] = append(waitsOn[n.name()], dep)
+			}
+		}
+	}
+
+	done := make(map[string]chan struct{}, len(sorted))
+	for _, n := range sorted {
+		done[n.name()] = make(chan struct{})
+	}
+
+	var mu sync.Mutex
+	failed := make(map[string]bool, len(sorted))
+	errs := make([]error, len(sorted))
+
+	var wg sync.WaitGroup
+	for i, n := range sorted {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[n.name()])
+
+			var blockedBy []string
+			for _, other := range waitsOn[n.name()] {
+				<-done[other]
+				mu.Lock()
+				if failed[other] {
+					blockedBy = append(blockedBy, other)
+				}
+				mu.Unlock()
+			}
+
+			var err error
+			if !reverse && len(blockedBy) > 0 {
+				err = fmt.Errorf("skipped %s: dependencies failed: %v", n.name(), blockedBy)
+			} else {
+				err = fn(n)
+			}
+
+			if err != nil {
+				mu.Lock()
+				failed[n.name()] = true
+				mu.Unlock()
+				errs[i] = err
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
 // extractOutput extracts the "output" field from entry data, handling both
 // map[string]any (loaded from disk) and concrete Snapshot structs (just created in memory).
 func extractOutput(data any) any {
@@ -200,7 +459,7 @@ func extractOutput(data any) any {
 // loadOutputs populates the output registry from lock file entries.
 // If handlerName is empty, all entries are loaded; otherwise only matching entries.
 func (r *Reconciler) loadOutputs(handlerName string) {
-	for _, entry := range r.lockFile.Entries {
+	for _, entry := range r.lockFile.snapshot() {
 		if handlerName != "" && entry.HandlerName != handlerName {
 			continue
 		}
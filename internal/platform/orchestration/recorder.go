@@ -0,0 +1,24 @@
+package orchestration
+
+import "time"
+
+// Operation is a resource operation reported to a Recorder.
+type Operation string
+
+const (
+	OperationCreate  Operation = "create"
+	OperationUpdate  Operation = "update"
+	OperationDestroy Operation = "destroy"
+)
+
+// Recorder receives the outcome of every resource operation the reconciler
+// performs, e.g. to export metrics. err is nil when the operation succeeded.
+// Implementations must be safe for concurrent use.
+type Recorder interface {
+	RecordOperation(handler string, op Operation, duration time.Duration, err error)
+}
+
+// nopRecorder is used when no Recorder is configured.
+type nopRecorder struct{}
+
+func (nopRecorder) RecordOperation(string, Operation, time.Duration, error) {}
@@ -0,0 +1,145 @@
+package orchestration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	logger "github.com/stupside/moley/v2/internal/platform/logging"
+)
+
+// ErrEntryNotFound is returned when no lock entry has the given handler and key.
+var ErrEntryNotFound = errors.New("no such lock entry")
+
+// ErrAlreadyTracked is returned when importing a resource the lock file tracks.
+var ErrAlreadyTracked = errors.New("resource is already tracked")
+
+// ErrIDMismatch is returned when importing with an ID that is not the ID of
+// the resource found for the key.
+var ErrIDMismatch = errors.New("resource ID does not match")
+
+// Entries returns every lock entry in dependency order. Nothing is checked or changed.
+func (r *Reconciler) Entries() ([]LockEntry, error) {
+	defer func() { _ = r.lockFile.Close() }()
+
+	sorted, err := r.topoSort()
+	if err != nil {
+		return nil, fmt.Errorf("dependency resolution failed: %w", err)
+	}
+
+	entries := r.lockFile.snapshot()
+	ordered := make([]LockEntry, 0, len(entries))
+	for _, n := range sorted {
+		for _, entry := range entries {
+			if entry.HandlerName == n.name() {
+				ordered = append(ordered, entry)
+			}
+		}
+	}
+	// Entries of handlers that are no longer registered come last.
+	for _, entry := range entries {
+		if _, ok := r.nodeMap[entry.HandlerName]; !ok {
+			ordered = append(ordered, entry)
+		}
+	}
+	return ordered, nil
+}
+
+// Forget removes a lock entry without destroying the resource, so Moley stops
+// managing it. The next Start creates or recovers it again if it is still desired.
+func (r *Reconciler) Forget(handlerName, key string) (LockEntry, error) {
+	defer func() { _ = r.lockFile.Close() }()
+
+	entry, ok := r.lockFile.find(handlerName, key)
+	if !ok {
+		return LockEntry{}, fmt.Errorf("%w: %s/%s", ErrEntryNotFound, handlerName, key)
+	}
+
+	r.lockFile.remove(handlerName, key)
+	if err := r.lockFile.Save(); err != nil {
+		return LockEntry{}, fmt.Errorf("failed to save lock file: %w", err)
+	}
+
+	logger.Infof("Forgot resource", map[string]any{"handler": handlerName, "key": key})
+	return entry, nil
+}
+
+// Import adopts an existing resource into the lock file. The desired input with
+// the given key is resolved from upstream outputs already in the lock file, then
+// the resource is found through the handler's Recover. A non-empty id must be
+// the provider ID of that resource. Nothing is created or destroyed.
+func (r *Reconciler) Import(ctx context.Context, handlerName, key, id string) (LockEntry, error) {
+	defer func() { _ = r.lockFile.Close() }()
+
+	n, ok := r.nodeMap[handlerName]
+	if !ok {
+		names := make([]string, 0, len(r.nodes))
+		for _, other := range r.nodes {
+			names = append(names, other.name())
+		}
+		return LockEntry{}, fmt.Errorf("unknown handler %q (expected one of %s)", handlerName, strings.Join(names, ", "))
+	}
+	if _, tracked := r.lockFile.find(handlerName, key); tracked {
+		return LockEntry{}, fmt.Errorf("%w: %s/%s", ErrAlreadyTracked, handlerName, key)
+	}
+
+	r.loadOutputs("")
+	if err := n.resolve(r.outputs); err != nil {
+		return LockEntry{}, fmt.Errorf("input resolution failed: %s: %w", handlerName, err)
+	}
+
+	if err := n.importResource(ctx, r.lockFile, key, id); err != nil {
+		return LockEntry{}, err
+	}
+	if err := r.lockFile.Save(); err != nil {
+		return LockEntry{}, fmt.Errorf("failed to save lock file: %w", err)
+	}
+
+	entry, _ := r.lockFile.find(handlerName, key)
+	logger.Infof("Imported resource", map[string]any{"handler": handlerName, "key": key})
+	return entry, nil
+}
+
+// Import finds the desired resource with the given key and records it.
+func (rm *nodeManager[TInput, TOutput]) Import(ctx context.Context, inputs []TInput, key, id string) error {
+	idx := slices.IndexFunc(inputs, func(input TInput) bool {
+		return rm.handler.Key(input) == key
+	})
+	if idx < 0 {
+		keys := make([]string, len(inputs))
+		for i, input := range inputs {
+			keys[i] = rm.handler.Key(input)
+		}
+		return fmt.Errorf("%s has no desired resource %q (configured keys: %s)", rm.handler.Name(), key, strings.Join(keys, ", "))
+	}
+	input := inputs[idx]
+
+	// Importing is an explicit adoption, so resources moley did not create are accepted.
+	output, status, err := rm.handler.Recover(ctx, input)
+	if err != nil && !(status == StatusUp && errors.Is(err, ErrNotOwned)) {
+		return fmt.Errorf("failed to find %s/%s: %w", rm.handler.Name(), key, err)
+	}
+	if status != StatusUp {
+		return fmt.Errorf("failed to find %s/%s: %w", rm.handler.Name(), key, ErrResourceMissing)
+	}
+
+	if id != "" {
+		identifier, ok := rm.handler.(Identifier[TOutput])
+		if !ok {
+			return fmt.Errorf("%s resources have no provider ID; omit the ID to import by key", rm.handler.Name())
+		}
+		found, err := identifier.ID(ctx, output)
+		if err != nil {
+			return fmt.Errorf("failed to read the ID of %s/%s: %w", rm.handler.Name(), key, err)
+		}
+		if found != id {
+			return fmt.Errorf("%s/%s is %s, not %s: %w", rm.handler.Name(), key, found, id, ErrIDMismatch)
+		}
+	}
+
+	health := rm.checkHealth(ctx, output)
+	rm.addToRegistry(Snapshot[TInput, TOutput]{Input: input, Output: output}, &health)
+	return nil
+}
@@ -0,0 +1,38 @@
+package orchestration
+
+import "time"
+
+// StatusVersion is the schema version of the serialized StatusReport.
+const StatusVersion = 1
+
+// ResourceStatus is the checked state of one resource tracked in the lock file.
+// Reason explains any status other than up, such as why a resource is degraded
+// or why its status could not be determined. Details is the handler's one-line
+// summary, if it has one.
+type ResourceStatus struct {
+	Handler    string    `json:"handler"`
+	Key        string    `json:"key"`
+	Status     Status    `json:"status"`
+	Reason     string    `json:"reason,omitempty"`
+	ObservedAt time.Time `json:"observed_at,omitzero"`
+	Details    string    `json:"details,omitempty"`
+	Input      any       `json:"input,omitempty"`
+	Output     any       `json:"output,omitempty"`
+}
+
+// StatusReport lists every tracked resource in dependency order.
+type StatusReport struct {
+	Version   int              `json:"version"`
+	Resources []ResourceStatus `json:"resources"`
+}
+
+// Count returns the number of resources in the given status.
+func (r *StatusReport) Count(status Status) int {
+	n := 0
+	for _, res := range r.Resources {
+		if res.Status == status {
+			n++
+		}
+	}
+	return n
+}
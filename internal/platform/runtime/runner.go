@@ -13,6 +13,12 @@ type Runnable interface {
 	Start(ctx context.Context) error
 }
 
+// Watcher is implemented by a Runnable that keeps working after Start returns.
+// Watch blocks until ctx is done; a returned error stops the Runnable.
+type Watcher interface {
+	Watch(ctx context.Context) error
+}
+
 func StartManaged(ctx context.Context, r Runnable) error {
 	sigCtx, cancel := signal.NotifyContext(ctx, sys.GetShutdownSignals()...)
 	defer cancel()
@@ -23,6 +29,13 @@ func StartManaged(ctx context.Context, r Runnable) error {
 		defer close(errCh)
 		if err := r.Start(sigCtx); err != nil {
 			errCh <- err
+			return
+		}
+		if w, ok := r.(Watcher); ok {
+			if err := w.Watch(sigCtx); err != nil {
+				errCh <- err
+			}
+			return
 		}
 		<-ctx.Done()
 	}()
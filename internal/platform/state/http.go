@@ -0,0 +1,309 @@
+// Package state provides shared backends for the lock file, so several
+// machines can operate the same tunnels.
+package state
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	logger "github.com/stupside/moley/v2/internal/platform/logging"
+	framework "github.com/stupside/moley/v2/internal/platform/orchestration"
+)
+
+const (
+	// requestTimeout bounds every request to the state server.
+	requestTimeout = 30 * time.Second
+	// lockPollInterval is how often a held lock is retried until the timeout.
+	lockPollInterval = time.Second
+)
+
+// ErrConflict is returned when the state changed since it was read.
+var ErrConflict = errors.New("state was changed by another writer")
+
+// ErrNoETag is returned when the server does not return an ETag for the state,
+// without which writes cannot be made conditional.
+var ErrNoETag = errors.New("state server must return an ETag")
+
+// HTTPBackend stores state as an object on an HTTP server that supports
+// conditional PUT, authenticated by an optional bearer token, or on an
+// S3-compatible object store with requests signed by SigV4 (see NewS3Backend).
+// The lock is a second object created with If-None-Match: *, so exactly one
+// writer can hold it; writes carry If-Match with the ETag that was read, so a
+// writer that lost its lock cannot overwrite newer state. Readers do not lock:
+// one GET is always a consistent snapshot.
+type HTTPBackend struct {
+	url    string
+	token  string
+	signer *sigV4Signer
+	client *http.Client
+
+	mu   sync.Mutex
+	etag string // ETag of the last read or write, empty when no object exists
+}
+
+var _ framework.Backend = (*HTTPBackend)(nil)
+
+// NewHTTPBackend stores state at url. A non-empty token is sent as a bearer token.
+func NewHTTPBackend(url, token string) *HTTPBackend {
+	return &HTTPBackend{
+		url:    url,
+		token:  token,
+		client: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// NewS3Backend stores state at the object url of an S3-compatible store, such
+// as https://s3.eu-west-1.amazonaws.com/bucket/moley/demo.json. The store must
+// support conditional writes, which S3 and MinIO do.
+func NewS3Backend(url string, creds S3Credentials) *HTTPBackend {
+	return &HTTPBackend{
+		url:    url,
+		signer: &sigV4Signer{creds: creds, now: time.Now},
+		client: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+func (b *HTTPBackend) lockURL() string {
+	return b.url + ".lock"
+}
+
+func (b *HTTPBackend) Lock(ctx context.Context, readOnly bool) (func() error, error) {
+	if readOnly {
+		return func() error { return nil }, nil
+	}
+
+	holder, err := json.Marshal(framework.CurrentHolder())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal lock holder: %w", err)
+	}
+
+	for waited := false; ; waited = true {
+		resp, err := b.do(ctx, http.MethodPut, b.lockURL(), holder, map[string]string{"If-None-Match": "*"})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, b.lockedError()
+			}
+			return nil, fmt.Errorf("failed to acquire state lock: %w", err)
+		}
+		_ = resp.Body.Close()
+
+		switch {
+		case resp.StatusCode/100 == 2:
+			return b.releaseLock, nil
+		case !conditionFailed(resp):
+			return nil, fmt.Errorf("failed to acquire state lock: unexpected status %s", resp.Status)
+		}
+
+		if !waited {
+			fields := map[string]any{"state": b.url}
+			if h, _ := b.holder(ctx); h != nil {
+				fields["holder"] = h.String()
+			}
+			logger.Infof("Waiting for the state lock", fields)
+		}
+
+		select {
+		case <-time.After(lockPollInterval):
+		case <-ctx.Done():
+			return nil, b.lockedError()
+		}
+	}
+}
+
+// lockedError names the current holder once waiting for the lock gave up.
+func (b *HTTPBackend) lockedError() error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	h, _ := b.holder(ctx)
+	if h == nil {
+		return fmt.Errorf("%w: %s is held by another moley command", framework.ErrLocked, b.url)
+	}
+	return fmt.Errorf("%w: %s is held by %s", framework.ErrLocked, b.url, h)
+}
+
+// holder reads the lock object, nil when the lock is free.
+func (b *HTTPBackend) holder(ctx context.Context) (*framework.LockHolder, error) {
+	resp, err := b.do(ctx, http.MethodGet, b.lockURL(), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var h framework.LockHolder
+	if err := json.NewDecoder(resp.Body).Decode(&h); err != nil {
+		return nil, fmt.Errorf("failed to decode lock holder: %w", err)
+	}
+	return &h, nil
+}
+
+// BreakLock deletes a lock object left behind by a crashed holder. Holders on
+// other hosts cannot be checked, so breaking their lock requires force.
+//
+// The lock object has no expiry: a holder that crashed on another host keeps
+// the lock until someone breaks it with force.
+func (b *HTTPBackend) BreakLock(ctx context.Context, force bool) (*framework.LockHolder, error) {
+	h, err := b.holder(ctx)
+	if err != nil && !force {
+		return nil, fmt.Errorf("failed to read lock holder: %w", err)
+	}
+	if h == nil && err == nil {
+		return nil, nil
+	}
+	if h != nil && !force && h.Alive() {
+		return h, fmt.Errorf("%w: %s is held by %s", framework.ErrHolderAlive, b.url, h)
+	}
+
+	return h, b.deleteLock(ctx)
+}
+
+func (b *HTTPBackend) releaseLock() error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	return b.deleteLock(ctx)
+}
+
+func (b *HTTPBackend) deleteLock(ctx context.Context) error {
+	resp, err := b.do(ctx, http.MethodDelete, b.lockURL(), nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to release state lock: %w", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("failed to release state lock: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+func (b *HTTPBackend) Read() ([]byte, error) {
+	resp, err := b.do(context.Background(), http.MethodGet, b.url, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		b.setETag("")
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		return nil, fmt.Errorf("%w: GET %s returned none", ErrNoETag, b.url)
+	}
+	b.setETag(etag)
+	return data, nil
+}
+
+func (b *HTTPBackend) Write(data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Only overwrite the version that was read; create only if none existed.
+	headers := map[string]string{"If-None-Match": "*"}
+	if b.etag != "" {
+		headers = map[string]string{"If-Match": b.etag}
+	}
+
+	resp, err := b.do(context.Background(), http.MethodPut, b.url, data, headers)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+
+	switch {
+	case conditionFailed(resp):
+		return fmt.Errorf("%w: %s", ErrConflict, b.url)
+	case resp.StatusCode/100 != 2:
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	b.etag = resp.Header.Get("ETag")
+	if b.etag == "" {
+		// Some servers only return the ETag on GET. The lock keeps other
+		// writers out, so the version read back is the one just written.
+		if b.etag, err = b.readETag(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readETag reads the current ETag of the state object.
+func (b *HTTPBackend) readETag() (string, error) {
+	resp, err := b.do(context.Background(), http.MethodGet, b.url, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to read back state: unexpected status %s", resp.Status)
+	}
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		return "", fmt.Errorf("%w: neither PUT nor GET %s returned one", ErrNoETag, b.url)
+	}
+	return etag, nil
+}
+
+func (b *HTTPBackend) String() string {
+	return b.url
+}
+
+func (b *HTTPBackend) setETag(etag string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.etag = etag
+}
+
+// conditionFailed reports whether a conditional PUT lost. S3 answers 409 instead
+// of 412 when another conditional write to the object is in flight.
+func conditionFailed(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusPreconditionFailed || resp.StatusCode == http.StatusConflict
+}
+
+func (b *HTTPBackend) do(ctx context.Context, method, url string, body []byte, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if b.token != "" {
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(b.token))
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if b.signer != nil {
+		b.signer.sign(req, body)
+	}
+	return b.client.Do(req)
+}
@@ -0,0 +1,320 @@
+package state
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	framework "github.com/stupside/moley/v2/internal/platform/orchestration"
+)
+
+// objectStore is an in-memory HTTP object store that honours If-Match and
+// If-None-Match on PUT.
+type objectStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	etags   map[string]string
+	version int
+
+	putETag bool // return the ETag on PUT
+	getETag bool // return the ETag on GET
+}
+
+func newObjectStore(t *testing.T) *httptest.Server {
+	t.Helper()
+	return serveObjectStore(t, true, true)
+}
+
+func serveObjectStore(t *testing.T, putETag, getETag bool) *httptest.Server {
+	t.Helper()
+	store := &objectStore{objects: map[string][]byte{}, etags: map[string]string{}, putETag: putETag, getETag: getETag}
+	srv := httptest.NewServer(store)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func (s *objectStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := r.URL.Path
+	data, exists := s.objects[key]
+
+	switch r.Method {
+	case http.MethodGet:
+		if !exists {
+			http.NotFound(w, r)
+			return
+		}
+		if s.getETag {
+			w.Header().Set("ETag", s.etags[key])
+		}
+		_, _ = w.Write(data)
+	case http.MethodPut:
+		if r.Header.Get("If-None-Match") == "*" && exists {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		if match := r.Header.Get("If-Match"); match != "" && (!exists || match != s.etags[key]) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		s.version++
+		s.objects[key] = body
+		s.etags[key] = fmt.Sprintf(`"%d"`, s.version)
+		if s.putETag {
+			w.Header().Set("ETag", s.etags[key])
+		}
+	case http.MethodDelete:
+		delete(s.objects, key)
+		delete(s.etags, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestHTTPBackendRoundTrip(t *testing.T) {
+	srv := newObjectStore(t)
+	url := srv.URL + "/moley/demo.json"
+
+	lf, err := framework.OpenLockFile(NewHTTPBackend(url, ""), framework.LockFileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lf.Entries = []framework.LockEntry{{Key: "a", HandlerName: "node"}}
+	if err := lf.Save(); err != nil {
+		t.Fatal(err)
+	}
+	// A second save overwrites the version written by the first.
+	if err := lf.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := lf.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	lf2, err := framework.OpenLockFile(NewHTTPBackend(url, ""), framework.LockFileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lf2.Close() }()
+
+	if len(lf2.Entries) != 1 || lf2.Entries[0].Key != "a" {
+		t.Errorf("expected the saved entry on another backend instance, got %+v", lf2.Entries)
+	}
+}
+
+func TestHTTPBackendLearnsETagWhenPutOmitsIt(t *testing.T) {
+	srv := serveObjectStore(t, false, true)
+	backend := NewHTTPBackend(srv.URL+"/moley/demo.json", "")
+
+	// Reconcile saves after every node, so consecutive writes must all succeed.
+	for i := range 3 {
+		if err := backend.Write([]byte(`{"entries":[]}`)); err != nil {
+			t.Fatalf("write %d: %v", i+1, err)
+		}
+	}
+}
+
+func TestHTTPBackendRequiresETag(t *testing.T) {
+	srv := serveObjectStore(t, false, false)
+	url := srv.URL + "/moley/demo.json"
+
+	if err := NewHTTPBackend(url, "").Write([]byte(`{"entries":[]}`)); !errors.Is(err, ErrNoETag) {
+		t.Fatalf("expected ErrNoETag on the first write, got %v", err)
+	}
+	if _, err := NewHTTPBackend(url, "").Read(); !errors.Is(err, ErrNoETag) {
+		t.Fatalf("expected ErrNoETag when reading existing state, got %v", err)
+	}
+}
+
+func TestHTTPBackendLockIsExclusive(t *testing.T) {
+	srv := newObjectStore(t)
+	url := srv.URL + "/moley/demo.json"
+	ctx := context.Background()
+
+	first := NewHTTPBackend(url, "")
+	unlock, err := first.Lock(ctx, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second := NewHTTPBackend(url, "")
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = second.Lock(waitCtx, false)
+	if !errors.Is(err, framework.ErrLocked) {
+		t.Fatalf("expected ErrLocked while held, got %v", err)
+	}
+	if !strings.Contains(err.Error(), fmt.Sprintf("pid %d", os.Getpid())) {
+		t.Errorf("error should name the holder, got %v", err)
+	}
+
+	if err := unlock(); err != nil {
+		t.Fatal(err)
+	}
+	unlock, err = second.Lock(ctx, false)
+	if err != nil {
+		t.Fatalf("lock should be free after release: %v", err)
+	}
+	_ = unlock()
+}
+
+func TestHTTPBackendBreakLock(t *testing.T) {
+	srv := newObjectStore(t)
+	url := srv.URL + "/moley/demo.json"
+	ctx := context.Background()
+	backend := NewHTTPBackend(url, "")
+
+	if holder, err := backend.BreakLock(ctx, false); err != nil || holder != nil {
+		t.Fatalf("breaking a free lock should be a no-op, got %v, %v", holder, err)
+	}
+
+	// A live holder is refused unless forced.
+	if _, err := backend.Lock(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := backend.BreakLock(ctx, false); !errors.Is(err, framework.ErrHolderAlive) {
+		t.Fatalf("expected ErrHolderAlive, got %v", err)
+	}
+
+	// A holder on this host whose process is gone is cleared.
+	dead := framework.CurrentHolder()
+	dead.PID = deadPID(t)
+	data, _ := json.Marshal(dead)
+	req, _ := http.NewRequest(http.MethodPut, url+".lock", bytes.NewReader(data))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	holder, err := backend.BreakLock(ctx, false)
+	if err != nil || holder == nil || holder.PID != dead.PID {
+		t.Fatalf("expected the dead holder to be cleared, got %v, %v", holder, err)
+	}
+	unlock, err := backend.Lock(ctx, false)
+	if err != nil {
+		t.Fatalf("lock should be free after break: %v", err)
+	}
+	_ = unlock()
+}
+
+// deadPID returns the PID of a process that has exited.
+func deadPID(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	return cmd.Process.Pid
+}
+
+func TestHTTPBackendRefusesStaleWrite(t *testing.T) {
+	srv := newObjectStore(t)
+	url := srv.URL + "/moley/demo.json"
+
+	stale := NewHTTPBackend(url, "")
+	if _, err := stale.Read(); err != nil {
+		t.Fatal(err)
+	}
+
+	fresh := NewHTTPBackend(url, "")
+	if err := fresh.Write([]byte(`{"entries":[]}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := stale.Write([]byte(`{"entries":[]}`)); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict for a write based on an old read, got %v", err)
+	}
+}
+
+func TestHTTPBackendSendsToken(t *testing.T) {
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	if _, err := NewHTTPBackend(srv.URL+"/state.json", "secret").Read(); err != nil {
+		t.Fatal(err)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("expected bearer token, got %q", auth)
+	}
+}
+
+// signedOnly rejects requests that are not signed with SigV4 for the key AKID,
+// or whose payload hash does not match the body.
+func signedOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") ||
+			r.Header.Get("X-Amz-Content-Sha256") != sha256Hex(body) ||
+			(r.Header.Get("If-Match") != "" && !strings.Contains(auth, "if-match")) {
+			http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+func TestS3BackendSignsRequests(t *testing.T) {
+	store := &objectStore{objects: map[string][]byte{}, etags: map[string]string{}, putETag: true, getETag: true}
+	srv := httptest.NewServer(signedOnly(store))
+	defer srv.Close()
+
+	url := srv.URL + "/bucket/moley/demo.json"
+	creds := S3Credentials{Region: "eu-west-1", AccessKeyID: "AKID", SecretAccessKey: "secret"}
+
+	lf, err := framework.OpenLockFile(NewS3Backend(url, creds), framework.LockFileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lf.Entries = []framework.LockEntry{{Key: "a", HandlerName: "node"}}
+	for range 2 {
+		if err := lf.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := lf.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := NewS3Backend(url, creds).Read()
+	if err != nil || !strings.Contains(string(data), `"key":"a"`) {
+		t.Errorf("expected the saved state, got %s, %v", data, err)
+	}
+}
+
+func TestHTTPBackendTreatsConflictAsHeldLock(t *testing.T) {
+	// S3 answers 409 to a conditional write racing another one.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := NewHTTPBackend(srv.URL+"/state.json", "").Lock(ctx, false); !errors.Is(err, framework.ErrLocked) {
+		t.Fatalf("expected ErrLocked, got %v", err)
+	}
+}